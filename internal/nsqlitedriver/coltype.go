@@ -0,0 +1,201 @@
+package nsqlitedriver
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// affinity is the SQLite type affinity of a column.
+//
+// https://www.sqlite.org/datatype3.html#type_affinity
+type affinity int
+
+const (
+	affinityBlob affinity = iota
+	affinityText
+	affinityNumeric
+	affinityInteger
+	affinityReal
+)
+
+var (
+	scanTypeAny        = reflect.TypeOf((*any)(nil)).Elem()
+	scanTypeInt64      = reflect.TypeOf(int64(0))
+	scanTypeFloat64    = reflect.TypeOf(float64(0))
+	scanTypeBool       = reflect.TypeOf(false)
+	scanTypeString     = reflect.TypeOf("")
+	scanTypeBytes      = reflect.TypeOf([]byte(nil))
+	scanTypeTime       = reflect.TypeOf(time.Time{})
+	scanTypeJSONNumber = reflect.TypeOf(json.Number(""))
+)
+
+// columnType is the parsed declared type of a column.
+type columnType struct {
+	// name is the upper cased declared type without arguments, for example
+	// "VARCHAR" for "varchar(255)".
+	name string
+	// args are the numeric arguments of the declared type, for example
+	// [10, 2] for "DECIMAL(10,2)".
+	args []int64
+	// affinity is the SQLite affinity of the declared type.
+	affinity affinity
+	// isTime is true for DATE, DATETIME, TIME and TIMESTAMP columns.
+	isTime bool
+	// isBool is true for BOOL and BOOLEAN columns.
+	isBool bool
+}
+
+// parseColumnType parses the declared type of a column as returned by the
+// server.
+func parseColumnType(declared string) columnType {
+	declared = strings.ToUpper(strings.TrimSpace(declared))
+
+	name := declared
+	var args []int64
+	if start := strings.IndexByte(declared, '('); start >= 0 {
+		name = strings.TrimSpace(declared[:start])
+		args = parseTypeArgs(declared[start+1:])
+	}
+
+	ct := columnType{
+		name:     name,
+		args:     args,
+		affinity: declaredAffinity(declared),
+	}
+
+	switch name {
+	case "DATE", "DATETIME", "TIME", "TIMESTAMP":
+		ct.isTime = true
+	case "BOOL", "BOOLEAN":
+		ct.isBool = true
+	}
+
+	return ct
+}
+
+// parseTypeArgs parses the arguments of a declared type, such as "10, 2)".
+// It returns nil if they are not all integers.
+func parseTypeArgs(s string) []int64 {
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil
+	}
+
+	parts := strings.Split(s[:end], ",")
+	args := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		arg, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil
+		}
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+// declaredAffinity returns the affinity of an upper cased declared type
+// following the SQLite rules, applied in order.
+func declaredAffinity(declared string) affinity {
+	switch {
+	case strings.Contains(declared, "INT"):
+		return affinityInteger
+	case strings.Contains(declared, "CHAR"),
+		strings.Contains(declared, "CLOB"),
+		strings.Contains(declared, "TEXT"):
+		return affinityText
+	case strings.Contains(declared, "BLOB"), declared == "":
+		return affinityBlob
+	case strings.Contains(declared, "REAL"),
+		strings.Contains(declared, "FLOA"),
+		strings.Contains(declared, "DOUB"):
+		return affinityReal
+	}
+	return affinityNumeric
+}
+
+// scanType returns the Go type of the values of the column as converted with
+// the given value mode.
+func (ct columnType) scanType(mode ValueMode) reflect.Type {
+	if ct.name == "" {
+		return scanTypeAny
+	}
+
+	if mode == ValueModeRaw {
+		switch ct.affinity {
+		case affinityInteger, affinityReal, affinityNumeric:
+			return scanTypeJSONNumber
+		}
+		return scanTypeString
+	}
+
+	switch {
+	case ct.isBool:
+		return scanTypeBool
+	case ct.isTime:
+		return scanTypeTime
+	}
+
+	switch ct.affinity {
+	case affinityInteger:
+		return scanTypeInt64
+	case affinityReal:
+		return scanTypeFloat64
+	case affinityText:
+		return scanTypeString
+	case affinityBlob:
+		return scanTypeBytes
+	}
+
+	if ct.hasScale() {
+		return scanTypeFloat64
+	}
+	return scanTypeAny
+}
+
+// hasScale reports whether the column is a numeric column with a scale, such
+// as "DECIMAL(10,2)". SQLite stores its whole values as integers, but they
+// are always converted to float64.
+func (ct columnType) hasScale() bool {
+	_, scale, ok := ct.precisionScale()
+	return ct.affinity == affinityNumeric && ok && scale > 0
+}
+
+// length returns the length of variable length text and binary columns. Types
+// without a declared length, such as TEXT or BLOB, return math.MaxInt64.
+func (ct columnType) length() (int64, bool) {
+	if ct.name == "" || ct.isTime {
+		return 0, false
+	}
+
+	switch ct.affinity {
+	case affinityText, affinityBlob:
+		if len(ct.args) == 1 {
+			return ct.args[0], true
+		}
+		return math.MaxInt64, true
+	}
+
+	return 0, false
+}
+
+// precisionScale returns the precision and scale of decimal columns, such as
+// 10 and 2 for "DECIMAL(10,2)".
+func (ct columnType) precisionScale() (int64, int64, bool) {
+	if ct.affinity != affinityNumeric && ct.affinity != affinityReal {
+		return 0, 0, false
+	}
+
+	switch len(ct.args) {
+	case 1:
+		return ct.args[0], 0, true
+	case 2:
+		return ct.args[0], ct.args[1], true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,124 @@
+package nsqlitedriver
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseColumnType(t *testing.T) {
+	tests := []struct {
+		declared string
+		want     columnType
+	}{
+		{declared: "", want: columnType{affinity: affinityBlob}},
+		{declared: "INTEGER", want: columnType{name: "INTEGER", affinity: affinityInteger}},
+		{declared: "bigint", want: columnType{name: "BIGINT", affinity: affinityInteger}},
+		{
+			declared: "varchar(255)",
+			want:     columnType{name: "VARCHAR", args: []int64{255}, affinity: affinityText},
+		},
+		{declared: "CLOB", want: columnType{name: "CLOB", affinity: affinityText}},
+		{declared: "BLOB", want: columnType{name: "BLOB", affinity: affinityBlob}},
+		{declared: "DOUBLE PRECISION", want: columnType{name: "DOUBLE PRECISION", affinity: affinityReal}},
+		{
+			declared: "DECIMAL(10, 2)",
+			want:     columnType{name: "DECIMAL", args: []int64{10, 2}, affinity: affinityNumeric},
+		},
+		{declared: "DATETIME", want: columnType{name: "DATETIME", affinity: affinityNumeric, isTime: true}},
+		{declared: "BOOLEAN", want: columnType{name: "BOOLEAN", affinity: affinityNumeric, isBool: true}},
+		{declared: "CHAR(x)", want: columnType{name: "CHAR", affinity: affinityText}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.declared, func(t *testing.T) {
+			if got := parseColumnType(tt.declared); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseColumnType(%q) = %+v, want %+v", tt.declared, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnTypeMetadata(t *testing.T) {
+	tests := []struct {
+		declared      string
+		mode          ValueMode
+		wantScanType  reflect.Type
+		wantLength    int64
+		wantLengthOk  bool
+		wantPrecision int64
+		wantScale     int64
+		wantDecimalOk bool
+	}{
+		{declared: "", wantScanType: scanTypeAny},
+		{declared: "INTEGER", wantScanType: scanTypeInt64},
+		{declared: "REAL", wantScanType: scanTypeFloat64},
+		{
+			declared:     "VARCHAR(255)",
+			wantScanType: scanTypeString,
+			wantLength:   255,
+			wantLengthOk: true,
+		},
+		{
+			declared:     "TEXT",
+			wantScanType: scanTypeString,
+			wantLength:   math.MaxInt64,
+			wantLengthOk: true,
+		},
+		{
+			declared:     "BLOB",
+			wantScanType: scanTypeBytes,
+			wantLength:   math.MaxInt64,
+			wantLengthOk: true,
+		},
+		{
+			declared:      "DECIMAL(10,2)",
+			wantScanType:  scanTypeFloat64,
+			wantPrecision: 10,
+			wantScale:     2,
+			wantDecimalOk: true,
+		},
+		{
+			declared:      "NUMERIC(8)",
+			wantScanType:  scanTypeAny,
+			wantPrecision: 8,
+			wantDecimalOk: true,
+		},
+		{declared: "DATETIME", wantScanType: scanTypeTime},
+		{declared: "BOOLEAN", wantScanType: scanTypeBool},
+		{declared: "INTEGER", mode: ValueModeRaw, wantScanType: scanTypeJSONNumber},
+		{
+			declared:     "BLOB",
+			mode:         ValueModeRaw,
+			wantScanType: scanTypeString,
+			wantLength:   math.MaxInt64,
+			wantLengthOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.declared, func(t *testing.T) {
+			ct := parseColumnType(tt.declared)
+
+			if got := ct.scanType(tt.mode); got != tt.wantScanType {
+				t.Errorf("scanType() = %v, want %v", got, tt.wantScanType)
+			}
+
+			length, ok := ct.length()
+			if length != tt.wantLength || ok != tt.wantLengthOk {
+				t.Errorf(
+					"length() = %d, %v, want %d, %v",
+					length, ok, tt.wantLength, tt.wantLengthOk,
+				)
+			}
+
+			precision, scale, ok := ct.precisionScale()
+			if precision != tt.wantPrecision || scale != tt.wantScale || ok != tt.wantDecimalOk {
+				t.Errorf(
+					"precisionScale() = %d, %d, %v, want %d, %d, %v",
+					precision, scale, ok, tt.wantPrecision, tt.wantScale, tt.wantDecimalOk,
+				)
+			}
+		})
+	}
+}
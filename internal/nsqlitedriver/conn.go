@@ -2,10 +2,12 @@ package nsqlitedriver
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 
+	"github.com/nsqlite/nsqlitego/internal/nsqlitelex"
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
@@ -13,6 +15,7 @@ var (
 	_ driver.Conn               = (*Conn)(nil)
 	_ driver.ConnBeginTx        = (*Conn)(nil)
 	_ driver.ConnPrepareContext = (*Conn)(nil)
+	_ driver.NamedValueChecker  = (*Conn)(nil)
 	_ driver.Pinger             = (*Conn)(nil)
 	_ driver.SessionResetter    = (*Conn)(nil)
 	_ driver.Validator          = (*Conn)(nil)
@@ -23,6 +26,9 @@ type Conn struct {
 	// client is the HTTP client used to communicate with the NSQLite server.
 	client *nsqlitehttp.Client
 
+	// config is the driver behavior inherited from the connector.
+	config *config
+
 	// txID is the ID of the current transaction, if empty no transaction is
 	// active.
 	//
@@ -36,6 +42,23 @@ type Conn struct {
 	//
 	// https://pkg.go.dev/database/sql/driver#Stmt
 	txID string
+
+	// txReadOnly is true when the current transaction was started with
+	// driver.TxOptions.ReadOnly, write statements are rejected while it is
+	// set.
+	txReadOnly bool
+
+	// savepoints is the stack of active savepoints of the current transaction,
+	// including the ones created by nested transactions.
+	savepoints []savepoint
+
+	// bad is true after a transport failure, the connection is then
+	// discarded by database/sql instead of being reused.
+	bad bool
+
+	// release drops the registry reference of client when the connection was
+	// created by Driver.Open, otherwise it is nil.
+	release func()
 }
 
 // Prepare creates a prepared statement with the given query.
@@ -44,15 +67,27 @@ func (c *Conn) Prepare(query string) (driver.Stmt, error) {
 }
 
 // PrepareContext creates a prepared statement with the given query and context.
+//
+// The query is parsed locally to know its placeholders, so a query mixing
+// positional and named placeholders is rejected without reaching the server.
 func (c *Conn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Stmt{
-		conn:  c,
-		query: query,
+		conn:   c,
+		parsed: parsed,
 	}, nil
 }
 
 // Close closes the connection, releasing any open resources.
 func (c *Conn) Close() error {
+	if c.release != nil {
+		defer c.release()
+	}
+
 	if err := c.RollbackTx(context.Background()); err != nil {
 		return fmt.Errorf("failed closing connection: %w", err)
 	}
@@ -65,24 +100,33 @@ func (c *Conn) Begin() (driver.Tx, error) {
 }
 
 // BeginTx starts a new transaction with the provided context.
+//
+// See beginQuery for how the isolation level is mapped onto SQLite.
+//
+// If a transaction is already active a nested transaction is started using a
+// savepoint, committing it releases the savepoint and rolling it back reverts
+// the changes made since it was started.
 func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	resp, err := c.client.SendQuery(ctx, nsqlitehttp.Query{
-		Query: "BEGIN;",
-	})
+	if c.config.readOnly {
+		opts.ReadOnly = true
+	}
+	if c.txID != "" {
+		return c.beginNestedTx(ctx, opts)
+	}
+
+	query, err := beginQuery(ctx, opts, c.config.txBeginMode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("failed to begin transaction: %s", resp.Error)
-	}
-	if resp.TxID == "" {
-		return nil, fmt.Errorf("transaction ID not returned from server")
+
+	resp, err := c.sendTxQuery(ctx, nsqlitehttp.HookOpBegin, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	c.setTxId(resp.TxID)
-	return &Tx{
-		conn: c,
-	}, nil
+	c.txReadOnly = opts.ReadOnly
+	return newTx(ctx, c, ""), nil
 }
 
 // CommitTx commits the transaction if any, otherwise does nothing.
@@ -92,16 +136,9 @@ func (c *Conn) CommitTx(ctx context.Context) error {
 		return nil
 	}
 
-	resp, err := c.client.SendQuery(ctx, nsqlitehttp.Query{
-		Query: "COMMIT",
-		TxID:  c.txID,
-	})
-	if err != nil {
+	if _, err := c.sendTxQuery(ctx, nsqlitehttp.HookOpCommit, "COMMIT"); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-	if resp.Error != "" {
-		return fmt.Errorf("failed to commit transaction: %s", resp.Error)
-	}
 
 	return nil
 }
@@ -113,33 +150,106 @@ func (c *Conn) RollbackTx(ctx context.Context) error {
 		return nil
 	}
 
-	resp, err := c.client.SendQuery(ctx, nsqlitehttp.Query{
-		Query: "ROLLBACK",
-		TxID:  c.txID,
-	})
-	if err != nil {
+	if _, err := c.sendTxQuery(ctx, nsqlitehttp.HookOpRollback, "ROLLBACK"); err != nil {
 		return fmt.Errorf("failed to rollback transaction: %w", err)
 	}
-	if resp.Error != "" {
-		return fmt.Errorf("failed to rollback transaction: %s", resp.Error)
-	}
 
 	return nil
 }
 
-// setTxId sets the transaction ID for the connection.
+// sendTxQuery sends the BEGIN, COMMIT or ROLLBACK statements of the current
+// transaction, or the savepoint statements of a nested one, between the hooks
+// of the connector. It returns the response of the last statement.
+func (c *Conn) sendTxQuery(ctx context.Context, op nsqlitehttp.HookOp, statements ...string) (nsqlitehttp.QueryResponse, error) {
+	event := &nsqlitehttp.HookEvent{
+		Op:   op,
+		TxID: c.txID,
+	}
+	for _, statement := range statements {
+		event.Queries = append(event.Queries, nsqlitehttp.Query{Query: statement, TxID: c.txID})
+	}
+	nested := c.txID != ""
+
+	var resp nsqlitehttp.QueryResponse
+	err := c.hook(ctx, event, func(ctx context.Context) error {
+		responses, err := c.client.SendQueries(ctx, event.Queries)
+		if err != nil {
+			return c.requestError(err)
+		}
+		resp = responses[len(responses)-1]
+		event.SetResponses(responses)
+
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		if op == nsqlitehttp.HookOpBegin && !nested {
+			if resp.TxID == "" {
+				return errors.New("transaction ID not returned from server")
+			}
+			event.TxID = resp.TxID
+		}
+		return nil
+	})
+
+	return resp, err
+}
+
+// setTxId sets the transaction ID for the connection, an empty ID also
+// clears the state of the finished transaction.
 func (c *Conn) setTxId(txId string) {
 	c.txID = txId
+	if txId == "" {
+		c.txReadOnly = false
+		c.savepoints = nil
+	}
+}
+
+// beginNestedTx starts a nested transaction using a savepoint.
+func (c *Conn) beginNestedTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New(
+			"failed to begin nested transaction: isolation level can not be changed",
+		)
+	}
+
+	name := c.nextSavepointName()
+	if err := c.Savepoint(ctx, name); err != nil {
+		return nil, fmt.Errorf("failed to begin nested transaction: %w", err)
+	}
+	if opts.ReadOnly {
+		c.txReadOnly = true
+	}
+
+	return newTx(ctx, c, name), nil
+}
+
+// checkQuery returns an error if the query is not allowed in the current
+// state of the connection.
+func (c *Conn) checkQuery(query string) error {
+	if !c.txReadOnly && !c.config.readOnly {
+		return nil
+	}
+	if nsqlitelex.IsReadOnly(query) {
+		return nil
+	}
+
+	if c.txReadOnly {
+		return fmt.Errorf("%w: transaction is read-only", ErrReadOnly)
+	}
+	return fmt.Errorf("%w: connection is read-only", ErrReadOnly)
 }
 
 // Ping verifies that the connection is still alive.
 func (c *Conn) Ping(ctx context.Context) error {
-	return c.client.SendPing(ctx)
+	return c.requestError(c.client.SendPing(ctx))
 }
 
 // ResetSession resets the session state used when the connection was used
 // before and needs to be reused.
 func (c *Conn) ResetSession(ctx context.Context) error {
+	if c.bad {
+		return driver.ErrBadConn
+	}
 	if err := c.RollbackTx(ctx); err != nil {
 		return errors.Join(
 			driver.ErrBadConn, errors.New("error resetting session"), err,
@@ -151,5 +261,8 @@ func (c *Conn) ResetSession(ctx context.Context) error {
 // IsValid is called prior to placing the connection into the connection pool.
 // The connection will be discarded if false is returned.
 func (c *Conn) IsValid() bool {
+	if c.bad {
+		return false
+	}
 	return c.client.IsHealthy(context.Background()) == nil
 }
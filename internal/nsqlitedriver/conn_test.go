@@ -0,0 +1,149 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// fakeRequest is a batch received by a fakeServer.
+type fakeRequest struct {
+	header  http.Header
+	queries []nsqlitehttp.Query
+}
+
+// fakeServer is an NSQLite server that answers every batch with the body
+// returned by respond and records the batches it receives.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []fakeRequest
+}
+
+// newFakeServer starts a fakeServer, closed when the test ends.
+func newFakeServer(t *testing.T, respond func(queries []nsqlitehttp.Query) string) *fakeServer {
+	t.Helper()
+
+	s := &fakeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.Write([]byte("OK"))
+			return
+		}
+
+		queries := []nsqlitehttp.Query{}
+		if err := json.NewDecoder(r.Body).Decode(&queries); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, fakeRequest{header: r.Header.Clone(), queries: queries})
+		s.mu.Unlock()
+
+		w.Write([]byte(respond(queries)))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// received returns the batches received so far and forgets them.
+func (s *fakeServer) received() []fakeRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := s.requests
+	s.requests = nil
+	return requests
+}
+
+// connect returns a connection to the server with the given options.
+func (s *fakeServer) connect(t *testing.T, options ...ConnectorOption) *Conn {
+	t.Helper()
+
+	client, err := nsqlitehttp.NewClient(s.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	conn, err := NewConnector(client, options...).Connect(context.Background())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	return conn.(*Conn)
+}
+
+// txResponder answers BEGIN with the transaction "tx1" and every other
+// statement with a write of one row.
+func txResponder(queries []nsqlitehttp.Query) string {
+	switch queries[0].Query {
+	case "BEGIN DEFERRED;":
+		return `{"results":[{"type":"begin","txId":"tx1"}]}`
+	case "COMMIT":
+		return `{"results":[{"type":"commit"}]}`
+	case "ROLLBACK":
+		return `{"results":[{"type":"rollback"}]}`
+	}
+	return `{"results":[{"type":"write","rowsAffected":1}]}`
+}
+
+func TestTxKeepsBeginContext(t *testing.T) {
+	server := newFakeServer(t, txResponder)
+	client, err := nsqlitehttp.NewClient(server.URL + "?authToken=default")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	db := sql.OpenDB(NewConnector(client))
+	defer db.Close()
+
+	for _, end := range []string{"COMMIT", "ROLLBACK"} {
+		t.Run(end, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			ctx = nsqlitehttp.WithRequestAuth(ctx, "tenant-a")
+			ctx = nsqlitehttp.WithRequestHeaders(ctx, http.Header{"X-Tenant": {"a"}})
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatalf("failed to begin transaction: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, "DELETE FROM t"); err != nil {
+				t.Fatalf("failed to execute: %v", err)
+			}
+
+			if end == "COMMIT" {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil {
+				t.Fatalf("failed to end transaction: %v", err)
+			}
+			cancel()
+
+			requests := server.received()
+			if len(requests) != 3 {
+				t.Fatalf("expected 3 requests, got %d", len(requests))
+			}
+			if got := requests[2].queries[0].Query; got != end {
+				t.Errorf("expected %s, got %q", end, got)
+			}
+			for _, request := range requests {
+				auth := request.header.Get("Authorization")
+				tenant := request.header.Get("X-Tenant")
+				if auth != "tenant-a" || tenant != "a" {
+					t.Errorf("%s: expected the tenant auth and headers, got %q and %q",
+						request.queries[0].Query, auth, tenant)
+				}
+			}
+		})
+	}
+}
@@ -3,36 +3,240 @@ package nsqlitedriver
 import (
 	"context"
 	"database/sql/driver"
+	"io"
+	"log/slog"
+	"time"
 
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
-var _ driver.Connector = (*nsqliteConnector)(nil)
+var (
+	_ driver.Connector = (*nsqliteConnector)(nil)
+	_ io.Closer        = (*nsqliteConnector)(nil)
+)
+
+// config holds the driver behavior shared by a connector and its connections.
+type config struct {
+	// valueMode selects how the values returned by the server are converted.
+	valueMode ValueMode
+	// timeFormats are the layouts used to parse the values of time columns.
+	timeFormats []string
+	// timeLocationValue is the location of the parsed time values, UTC if nil.
+	timeLocationValue *time.Location
+	// paramEncoder is the custom encoder of the query arguments, if any.
+	paramEncoder ParamEncoder
+	// paramTimeFormat selects how time.Time arguments are sent.
+	paramTimeFormat TimeFormat
+	// paramTimeLayout is the layout of time.Time arguments sent as text.
+	paramTimeLayout string
+	// statementTimeout is the timeout of statements executed without a
+	// context deadline, 0 means no timeout.
+	statementTimeout time.Duration
+	// readOnly rejects every write statement and starts read-only
+	// transactions.
+	readOnly bool
+	// txBeginMode is the mode of transactions started with the default
+	// isolation level.
+	txBeginMode TxBeginMode
+	// hooks are called around every statement and transaction.
+	hooks nsqlitehttp.HookChain
+	// tracer starts a span for every statement and transaction, nil when
+	// tracing is disabled.
+	tracer nsqlitehttp.Tracer
+	// logHook logs every statement and transaction, nil when logging is
+	// disabled.
+	logHook *nsqlitehttp.LogHook
+}
+
+// newConfig returns a config with the default values.
+func newConfig() *config {
+	return &config{
+		valueMode:       ValueModeTyped,
+		timeFormats:     DefaultTimeFormats,
+		paramTimeFormat: TimeFormatText,
+		paramTimeLayout: DefaultParamTimeLayout,
+		txBeginMode:     TxBeginDeferred,
+	}
+}
+
+// ConnectorOption is a function that configures a connector.
+type ConnectorOption func(*config)
+
+// WithValueMode sets how the values returned by the server are converted
+// before reaching sql.Rows.Scan. Default is ValueModeTyped.
+func WithValueMode(mode ValueMode) ConnectorOption {
+	return func(c *config) {
+		c.valueMode = mode
+	}
+}
+
+// WithTimeFormats sets the layouts tried in order to parse the values of
+// DATE, DATETIME, TIME and TIMESTAMP columns. Default is DefaultTimeFormats.
+func WithTimeFormats(formats ...string) ConnectorOption {
+	return func(c *config) {
+		c.timeFormats = formats
+	}
+}
+
+// WithTimeLocation sets the location of the time values parsed from time
+// columns. Values without an explicit offset are interpreted as UTC. Default
+// is UTC.
+func WithTimeLocation(location *time.Location) ConnectorOption {
+	return func(c *config) {
+		c.timeLocationValue = location
+	}
+}
+
+// WithParamEncoder sets a custom encoder for the query arguments, it is
+// called before the default encoding and can return driver.ErrSkip to use it.
+func WithParamEncoder(encoder ParamEncoder) ConnectorOption {
+	return func(c *config) {
+		c.paramEncoder = encoder
+	}
+}
+
+// WithParamTimeFormat sets how time.Time arguments are sent to the server.
+// Default is TimeFormatText.
+func WithParamTimeFormat(format TimeFormat) ConnectorOption {
+	return func(c *config) {
+		c.paramTimeFormat = format
+	}
+}
+
+// WithParamTimeLayout sets the layout of time.Time arguments sent with
+// TimeFormatText. Default is DefaultParamTimeLayout.
+func WithParamTimeLayout(layout string) ConnectorOption {
+	return func(c *config) {
+		c.paramTimeLayout = layout
+	}
+}
+
+// WithStatementTimeout sets the timeout of the statements executed with a
+// context without deadline, the deadline of the context is used otherwise.
+// For queries returning rows it also covers reading the rows. Default is no
+// timeout.
+func WithStatementTimeout(timeout time.Duration) ConnectorOption {
+	return func(c *config) {
+		c.statementTimeout = timeout
+	}
+}
+
+// WithReadOnly rejects every write statement with ErrReadOnly before it
+// reaches the server, and starts every transaction as read-only.
+func WithReadOnly(readOnly bool) ConnectorOption {
+	return func(c *config) {
+		c.readOnly = readOnly
+	}
+}
+
+// WithDefaultTxBeginMode sets the mode of the transactions started with the
+// default isolation level, WithTxBeginMode takes precedence over it. Default
+// is TxBeginDeferred.
+func WithDefaultTxBeginMode(mode TxBeginMode) ConnectorOption {
+	return func(c *config) {
+		c.txBeginMode = mode
+	}
+}
+
+// WithHooks adds hooks called around every Exec, Query, Begin, Commit and
+// Rollback of the connections. They are independent from the hooks of the
+// client, see nsqlitehttp.WithHooks.
+func WithHooks(hooks ...nsqlitehttp.Hook) ConnectorOption {
+	return func(c *config) {
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+// WithLogger logs every statement and transaction with their transaction
+// IDs, see nsqlitehttp.LogHook. Default is no logging.
+func WithLogger(logger *slog.Logger) ConnectorOption {
+	return func(c *config) {
+		c.ensureLogHook().Logger = logger
+	}
+}
+
+// WithSlowQueryThreshold logs at warn level the statements whose server
+// execution time exceeds threshold. It requires WithLogger.
+func WithSlowQueryThreshold(threshold time.Duration) ConnectorOption {
+	return func(c *config) {
+		c.ensureLogHook().SlowQueryThreshold = threshold
+	}
+}
+
+// WithLogParams logs the values of the query parameters instead of
+// redacting them. It requires WithLogger.
+func WithLogParams(logParams bool) ConnectorOption {
+	return func(c *config) {
+		c.ensureLogHook().LogParams = logParams
+	}
+}
+
+// WithTracer starts a span for every statement and transaction, see
+// nsqlitehttp.TraceHook. The span is propagated to the server with the
+// traceparent header.
+func WithTracer(tracer nsqlitehttp.Tracer) ConnectorOption {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// ensureLogHook returns the log hook of the config, creating it if needed.
+func (c *config) ensureLogHook() *nsqlitehttp.LogHook {
+	if c.logHook == nil {
+		c.logHook = &nsqlitehttp.LogHook{}
+	}
+	return c.logHook
+}
 
 // nsqliteConnector represents a driver in a fixed configuration and can create
 // any number of equivalent Conns for use by multiple goroutines.
 type nsqliteConnector struct {
 	httpClient *nsqlitehttp.Client
+	config     *config
+
+	// release drops the reference to httpClient held in the driver registry,
+	// it is nil when the client is owned by the caller of NewConnector.
+	release func()
 }
 
 // NewConnector returns a new NSQLite connector compatible with
 // database/sql.OpenDB
 //
-// It accepts a number of options to configure the connector.
-func NewConnector(nsqliteHTTPClient *nsqlitehttp.Client) driver.Connector {
+// The options configure the behavior of the connections created by the
+// connector, so differently tuned sql.DB can share the same client. The caller
+// keeps the ownership of the given client, closing the connector does not
+// close it.
+func NewConnector(nsqliteHTTPClient *nsqlitehttp.Client, options ...ConnectorOption) driver.Connector {
 	connector := &nsqliteConnector{
 		httpClient: nsqliteHTTPClient,
+		config:     newConfig(),
+	}
+
+	for _, opt := range options {
+		opt(connector.config)
 	}
 
+	conf := connector.config
+	conf.hooks = append(nsqlitehttp.BuiltinHooks(conf.tracer, conf.logHook), conf.hooks...)
+
 	return connector
 }
 
 // Connect returns a connection to the database.
 func (c *nsqliteConnector) Connect(_ context.Context) (driver.Conn, error) {
-	return &Conn{client: c.httpClient}, nil
+	return &Conn{client: c.httpClient, config: c.config}, nil
 }
 
 // Driver returns the underlying Driver of the Connector
 func (c *nsqliteConnector) Driver() driver.Driver {
 	return &Driver{}
 }
+
+// Close is called by database/sql when the owning sql.DB is closed and
+// releases the registry reference of the connector, if any.
+func (c *nsqliteConnector) Close() error {
+	if c.release != nil {
+		c.release()
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+func TestConnectorOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client, err := nsqlitehttp.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	connector := NewConnector(
+		client,
+		WithReadOnly(true),
+		WithStatementTimeout(10*time.Millisecond),
+		WithDefaultTxBeginMode(TxBeginImmediate),
+	)
+	driverConn, err := connector.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	conn := driverConn.(*Conn)
+
+	if conn.config.txBeginMode != TxBeginImmediate {
+		t.Errorf("expected tx begin mode %q, got %q", TxBeginImmediate, conn.config.txBeginMode)
+	}
+
+	_, err = conn.ExecContext(context.Background(), "DELETE FROM t", nil)
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got: %v", err)
+	}
+
+	_, err = conn.QueryContext(context.Background(), "SELECT 1", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got: %v", err)
+	}
+}
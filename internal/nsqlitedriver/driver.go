@@ -1,11 +1,13 @@
 package nsqlitedriver
 
 import (
-	"context"
 	"database/sql/driver"
 	"fmt"
+	"net/url"
+	"strings"
 	"sync"
 
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
@@ -18,45 +20,153 @@ var (
 type Driver struct {
 }
 
-var (
-	httpClient     *nsqlitehttp.Client
-	httpClientErr  error
-	httpClientOnce sync.Once
-)
+// registry is the process wide registry of NSQLite HTTP clients used by
+// Driver.Open and Driver.OpenConnector.
+var registry = newClientRegistry()
+
+// clientRegistry keeps one *nsqlitehttp.Client per normalized connection
+// string, so every sql.DB opened with an equivalent DSN shares the same
+// client while different DSNs get their own.
+type clientRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*registryEntry
+}
+
+// registryEntry is a reference counted client stored in the registry.
+type registryEntry struct {
+	client *nsqlitehttp.Client
+	refs   int
+}
+
+// newClientRegistry creates an empty client registry.
+func newClientRegistry() *clientRegistry {
+	return &clientRegistry{
+		entries: map[string]*registryEntry{},
+	}
+}
 
-// getNSQLiteHTTPClient creates a new NSQLite HTTP client singleton.
-func getNSQLiteHTTPClient(connectionString string) (*nsqlitehttp.Client, error) {
-	httpClientOnce.Do(func() {
+// acquire returns the client for the given connection string, creating it if
+// needed, and a release function that must be called once the caller no
+// longer uses the client. When the last reference is released the client is
+// closed and removed from the registry.
+func (r *clientRegistry) acquire(connectionString string) (*nsqlitehttp.Client, func(), error) {
+	key, err := normalizeConnectionString(connectionString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid connection string: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
 		hc, err := nsqlitehttp.NewClient(connectionString)
 		if err != nil {
-			httpClientErr = fmt.Errorf("failed to create NSQLite HTTP client: %v", err)
-			return
+			return nil, nil, err
+		}
+		entry = &registryEntry{client: hc}
+		r.entries[key] = entry
+	}
+	entry.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { r.release(key, entry) })
+	}
+
+	return entry.client, release, nil
+}
+
+// release drops one reference of the given entry.
+func (r *clientRegistry) release(key string, entry *registryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+
+	if r.entries[key] == entry {
+		delete(r.entries, key)
+	}
+	_ = entry.client.Close()
+}
+
+// len returns the number of clients currently held by the registry.
+func (r *clientRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// normalizeConnectionString returns a canonical form of the connection
+// string so equivalent DSNs (different parameter order, implicit default
+// port, letter case of the scheme and host) map to the same registry key.
+func normalizeConnectionString(connectionString string) (string, error) {
+	connStr, err := nsqlitedsn.NewConnStrFromText(connectionString)
+	if err != nil {
+		return "", err
+	}
+
+	key := ""
+	if connStr.SocketPath != "" {
+		// Socket paths are case sensitive.
+		key = connStr.Protocol + "://" + connStr.SocketPath
+	} else {
+		hosts := []string{}
+		for _, addr := range connStr.Addresses() {
+			hosts = append(hosts, addr.String())
 		}
+		key = strings.ToLower(connStr.Protocol + "://" + strings.Join(hosts, ","))
+	}
 
-		httpClient = hc
-		httpClientErr = nil
-	})
+	// Multi-host connection strings are not valid URLs, so only the query
+	// is parsed.
+	if _, rawQuery, ok := strings.Cut(connectionString, "?"); ok {
+		rawQuery, _, _ = strings.Cut(rawQuery, "#")
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return "", err
+		}
+		if encoded := query.Encode(); encoded != "" {
+			key += "?" + encoded
+		}
+	}
 
-	return httpClient, httpClientErr
+	return key, nil
 }
 
 // Open creates a new connection using the provided connection string.
+//
+// The returned connection holds a reference to the shared client of the
+// connection string that is released when the connection is closed.
 func (d *Driver) Open(connectionString string) (driver.Conn, error) {
-	httpClient, err := getNSQLiteHTTPClient(connectionString)
+	httpClient, release, err := registry.acquire(connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create NSQLite HTTP client: %v", err)
 	}
 
-	connector := NewConnector(httpClient)
-	return connector.Connect(context.Background())
+	return &Conn{
+		client:  httpClient,
+		config:  newConfig(),
+		release: release,
+	}, nil
 }
 
 // OpenConnector creates a new connector using the provided connection string.
+//
+// The returned connector holds a reference to the shared client of the
+// connection string that is released when the owning sql.DB is closed.
 func (d *Driver) OpenConnector(connectionString string) (driver.Connector, error) {
-	httpClient, err := getNSQLiteHTTPClient(connectionString)
+	httpClient, release, err := registry.acquire(connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create NSQLite HTTP client: %v", err)
 	}
 
-	return NewConnector(httpClient), nil
+	return &nsqliteConnector{
+		httpClient: httpClient,
+		config:     newConfig(),
+		release:    release,
+	}, nil
 }
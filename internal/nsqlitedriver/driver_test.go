@@ -0,0 +1,143 @@
+package nsqlitedriver
+
+import (
+	"testing"
+)
+
+func TestNormalizeConnectionString(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		expectError bool
+	}{
+		{
+			name:  "Default port is made explicit",
+			input: "http://example.com",
+			want:  "http://example.com:9876",
+		},
+		{
+			name:  "Scheme and host are lowercased",
+			input: "HTTP://Example.COM:8080?authToken=Secret",
+			want:  "http://example.com:8080?authToken=Secret",
+		},
+		{
+			name:  "Query parameters are sorted",
+			input: "https://example.com?timeout=5s&authToken=x&retries=1",
+			want:  "https://example.com:9876?authToken=x&retries=1&timeout=5s",
+		},
+		{
+			name:  "Multiple hosts",
+			input: "http://Primary,replica:9000?readFrom=replicas&authToken=x",
+			want:  "http://primary:9876,replica:9000?authToken=x&readFrom=replicas",
+		},
+		{
+			name:  "Unix socket path keeps its case",
+			input: "unix:///run/NSQLite.sock?authToken=x",
+			want:  "unix:///run/NSQLite.sock?authToken=x",
+		},
+		{
+			name:        "Unknown parameter",
+			input:       "http://example.com?foo=bar",
+			expectError: true,
+		},
+		{
+			name:        "Invalid connection string",
+			input:       "ftp://example.com",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeConnectionString(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeConnectionString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientRegistry(t *testing.T) {
+	t.Run("Equivalent DSNs share a client", func(t *testing.T) {
+		r := newClientRegistry()
+
+		c1, release1, err := r.acquire("http://example.com?authToken=a")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		c2, release2, err := r.acquire("http://EXAMPLE.com:9876?authToken=a")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if c1 != c2 {
+			t.Errorf("expected the same client for equivalent DSNs")
+		}
+		if r.len() != 1 {
+			t.Errorf("expected 1 registry entry, got %d", r.len())
+		}
+
+		release1()
+		release1()
+		if r.len() != 1 {
+			t.Errorf("expected entry to survive while referenced, got %d", r.len())
+		}
+
+		release2()
+		if r.len() != 0 {
+			t.Errorf("expected registry to be empty, got %d", r.len())
+		}
+	})
+
+	t.Run("Different DSNs get different clients", func(t *testing.T) {
+		r := newClientRegistry()
+
+		c1, release1, err := r.acquire("http://primary:9876?authToken=a")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer release1()
+		c2, release2, err := r.acquire("http://analytics:9876?authToken=b")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer release2()
+
+		if c1 == c2 {
+			t.Errorf("expected different clients for different DSNs")
+		}
+		if r.len() != 2 {
+			t.Errorf("expected 2 registry entries, got %d", r.len())
+		}
+	})
+
+	t.Run("Released DSN gets a new client", func(t *testing.T) {
+		r := newClientRegistry()
+
+		c1, release1, err := r.acquire("http://example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release1()
+
+		c2, release2, err := r.acquire("http://example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer release2()
+
+		if c1 == c2 {
+			t.Errorf("expected a new client after the previous one was released")
+		}
+	})
+}
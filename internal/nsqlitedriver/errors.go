@@ -0,0 +1,45 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// ErrTxInterrupted is returned when a request of an open transaction fails
+// because of a transport failure. The transaction can not be retried on
+// another connection and its state on the server is unknown, so the
+// connection is discarded.
+var ErrTxInterrupted = errors.New("transaction interrupted by a transport failure")
+
+// requestError maps the error of a request sent to the server to the error
+// reported to database/sql.
+//
+// Transport failures mark the connection as bad. When no transaction is open
+// and the request provably never reached the server, driver.ErrBadConn is
+// returned so database/sql can retry it on another connection. Inside a
+// transaction ErrTxInterrupted is returned instead, because retrying on
+// another connection would silently lose the transaction.
+//
+// Requests aborted because their context was canceled or its deadline
+// exceeded are not transport failures, the connection stays usable.
+func (c *Conn) requestError(err error) error {
+	if !nsqlitehttp.IsTransport(err) {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	c.bad = true
+	if c.txID != "" {
+		return fmt.Errorf("%w: %w", ErrTxInterrupted, err)
+	}
+	if nsqlitehttp.IsRequestNotSent(err) {
+		return fmt.Errorf("%w: %w", driver.ErrBadConn, err)
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+func TestRequestError(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	badGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer badGateway.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		txID         string
+		wantBadConn  bool
+		wantTxFailed bool
+	}{
+		{name: "Not sent without transaction", url: closed.URL, wantBadConn: true},
+		{name: "Not sent inside transaction", url: closed.URL, txID: "tx", wantTxFailed: true},
+		{name: "Maybe sent without transaction", url: badGateway.URL},
+		{name: "Maybe sent inside transaction", url: badGateway.URL, txID: "tx", wantTxFailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := nsqlitehttp.NewClient(tt.url)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			conn := &Conn{client: client, config: newConfig(), txID: tt.txID}
+			_, err = conn.ExecContext(context.Background(), "DELETE FROM t", nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if got := errors.Is(err, driver.ErrBadConn); got != tt.wantBadConn {
+				t.Errorf("errors.Is(err, driver.ErrBadConn) = %v, want %v: %v", got, tt.wantBadConn, err)
+			}
+			if got := errors.Is(err, ErrTxInterrupted); got != tt.wantTxFailed {
+				t.Errorf("errors.Is(err, ErrTxInterrupted) = %v, want %v: %v", got, tt.wantTxFailed, err)
+			}
+			if !conn.bad {
+				t.Errorf("expected the connection to be marked bad")
+			}
+			if err := conn.ResetSession(context.Background()); !errors.Is(err, driver.ErrBadConn) {
+				t.Errorf("expected ResetSession to return driver.ErrBadConn, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestSavepointRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := nsqlitehttp.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	conn := &Conn{client: client, config: newConfig(), txID: "tx"}
+	err = conn.Savepoint(context.Background(), "sp1")
+	if !errors.Is(err, ErrTxInterrupted) {
+		t.Errorf("expected ErrTxInterrupted, got: %v", err)
+	}
+	if !conn.bad {
+		t.Errorf("expected the connection to be marked bad")
+	}
+	if depth := conn.SavepointDepth(); depth != 0 {
+		t.Errorf("expected no savepoint, got %d", depth)
+	}
+}
+
+func TestRequestErrorCanceled(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client, err := nsqlitehttp.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	for _, txID := range []string{"", "tx"} {
+		conn := &Conn{client: client, config: newConfig(), txID: txID}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := conn.ExecContext(ctx, "DELETE FROM t", nil)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got: %v", err)
+		}
+		if errors.Is(err, driver.ErrBadConn) || errors.Is(err, ErrTxInterrupted) {
+			t.Errorf("expected a canceled statement not to fail the connection, got: %v", err)
+		}
+		if conn.bad {
+			t.Errorf("expected the connection to stay usable after a canceled statement")
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+var (
+	_ driver.ExecerContext  = (*Conn)(nil)
+	_ driver.QueryerContext = (*Conn)(nil)
+)
+
+// ExecContext executes a query without returning rows (e.g., INSERT, UPDATE)
+// without preparing a statement first.
+func (c *Conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return c.exec(ctx, parsed, args)
+}
+
+// QueryContext executes a query that returns rows (e.g., SELECT) without
+// preparing a statement first.
+func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return c.query(ctx, parsed, args)
+}
+
+// exec executes a query without returning rows.
+//
+// Every statement of the query is sent in the same batch, the result is the
+// one of the last statement and the error is the one of the first failed
+// statement. The statements are not atomic, the ones executed before a failed
+// statement are not rolled back.
+//
+// Every query executed by the connection, either prepared or direct, goes
+// through exec or query.
+func (c *Conn) exec(ctx context.Context, parsed *parsedQuery, args []driver.NamedValue) (driver.Result, error) {
+	if err := checkArgs(parsed.params, args); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.statementContext(ctx)
+	defer cancel()
+
+	event := &nsqlitehttp.HookEvent{
+		Op:      nsqlitehttp.HookOpExec,
+		Queries: parsed.queries(args, c.txID),
+		TxID:    c.txID,
+	}
+
+	var result driver.Result
+	err := c.hook(ctx, event, func(ctx context.Context) error {
+		if err := c.checkQueries(event.Queries); err != nil {
+			return err
+		}
+
+		responses, err := c.client.SendQueries(ctx, event.Queries)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", c.requestError(err))
+		}
+		event.SetResponses(responses)
+		for _, resp := range responses {
+			if err := resp.Err(); err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+		}
+
+		last := responses[len(responses)-1]
+		result = &ExecResult{
+			lastInsertId: last.LastInsertID,
+			rowsAffected: last.RowsAffected,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// query executes a query that returns rows, with one result set per
+// statement of the query.
+//
+// The After hooks of the connector are called when the rows are closed.
+func (c *Conn) query(ctx context.Context, parsed *parsedQuery, args []driver.NamedValue) (driver.Rows, error) {
+	if err := checkArgs(parsed.params, args); err != nil {
+		return nil, err
+	}
+
+	// The rows are read from the response body, so the statement context is
+	// canceled when the rows are closed.
+	ctx, cancel := c.statementContext(ctx)
+
+	event := &nsqlitehttp.HookEvent{
+		Op:      nsqlitehttp.HookOpQuery,
+		Queries: parsed.queries(args, c.txID),
+		TxID:    c.txID,
+	}
+	ctx, err := c.config.hooks.Before(ctx, event)
+	start := time.Now()
+	finish := func(err error) {
+		event.Duration = time.Since(start)
+		event.Err = err
+		c.config.hooks.After(ctx, event)
+		cancel()
+	}
+
+	if err == nil {
+		err = c.checkQueries(event.Queries)
+	}
+	if err != nil {
+		finish(err)
+		return nil, err
+	}
+
+	stream, err := c.client.StreamQueries(ctx, event.Queries)
+	if err != nil {
+		err = fmt.Errorf("failed to execute query: %w", c.requestError(err))
+		finish(err)
+		return nil, err
+	}
+
+	rows, err := newQueryRows(stream, c.config, len(event.Queries))
+	if err != nil {
+		stream.Close()
+		finish(err)
+		return nil, err
+	}
+
+	rows.onClose = func(responses []nsqlitehttp.QueryResponse, err error) {
+		event.SetResponses(responses)
+		finish(err)
+	}
+	return rows, nil
+}
+
+// statementContext returns the context of a statement, bounded by the
+// statement timeout of the connector if ctx has no deadline.
+func (c *Conn) statementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.config.statementTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.config.statementTimeout)
+}
@@ -0,0 +1,149 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+func TestConnExecContext(t *testing.T) {
+	server := newFakeServer(t, func(queries []nsqlitehttp.Query) string {
+		switch queries[0].Query {
+		case "BEGIN DEFERRED;":
+			return `{"results":[{"type":"begin","txId":"tx1"}]}`
+		case "INSERT INTO t VALUES (1)":
+			return `{"results":[{"type":"error","error":"UNIQUE constraint failed: t.id"}]}`
+		}
+		return `{"results":[{"type":"write","lastInsertId":3,"rowsAffected":1},` +
+			`{"type":"write","lastInsertId":7,"rowsAffected":2}]}`
+	})
+	conn := server.connect(t)
+	ctx := context.Background()
+
+	if _, err := conn.BeginTx(ctx, driver.TxOptions{}); err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	server.received()
+
+	_, err := conn.ExecContext(ctx, "INSERT INTO t VALUES (?); UPDATE t SET x = :x", []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Name: "x", Value: "a"},
+	})
+	if err == nil {
+		t.Fatalf("expected an error mixing positional and named arguments")
+	}
+
+	result, err := conn.ExecContext(ctx, "INSERT INTO t VALUES (?, ?); UPDATE t SET x = ?", []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "a"},
+		{Ordinal: 3, Value: 2.5},
+	})
+	if err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+	lastInsertID, _ := result.LastInsertId()
+	rowsAffected, _ := result.RowsAffected()
+	if lastInsertID != 7 || rowsAffected != 2 {
+		t.Errorf("expected the result of the last statement, got %d and %d", lastInsertID, rowsAffected)
+	}
+
+	requests := server.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	want := []nsqlitehttp.Query{
+		{
+			Query: "INSERT INTO t VALUES (?, ?)",
+			Params: []nsqlitehttp.QueryParam{
+				{Value: float64(1)},
+				{Value: "a"},
+			},
+			TxID: "tx1",
+		},
+		{
+			Query:  "UPDATE t SET x = ?",
+			Params: []nsqlitehttp.QueryParam{{Value: 2.5}},
+			TxID:   "tx1",
+		},
+	}
+	if !reflect.DeepEqual(requests[0].queries, want) {
+		t.Errorf("expected queries %+v, got %+v", want, requests[0].queries)
+	}
+
+	_, err = conn.ExecContext(ctx, "INSERT INTO t VALUES (1)", nil)
+	var nsqliteErr *nsqlitehttp.Error
+	if !errors.As(err, &nsqliteErr) || nsqliteErr.Code != nsqlitehttp.ErrorCodeConstraintUnique {
+		t.Errorf("expected a UNIQUE constraint error, got: %v", err)
+	}
+	if conn.bad {
+		t.Errorf("expected a failed statement to keep the connection usable")
+	}
+}
+
+func TestConnQueryContext(t *testing.T) {
+	server := newFakeServer(t, func(queries []nsqlitehttp.Query) string {
+		if queries[0].Query == "BEGIN DEFERRED;" {
+			return `{"results":[{"type":"begin","txId":"tx1"}]}`
+		}
+		return `{"results":[{"type":"read",` +
+			`"columns":["id","name","score","data"],` +
+			`"types":["INTEGER","TEXT","REAL","BLOB"],` +
+			`"rows":[[1,"a",1.5,"AQI="],[2,null,2,null]]}]}`
+	})
+	conn := server.connect(t)
+	ctx := context.Background()
+
+	if _, err := conn.BeginTx(ctx, driver.TxOptions{}); err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	server.received()
+
+	rows, err := conn.QueryContext(ctx, "SELECT id, name, score, data FROM t WHERE id > :id", []driver.NamedValue{
+		{Ordinal: 1, Name: "id", Value: int64(0)},
+	})
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	if got := rows.Columns(); !reflect.DeepEqual(got, []string{"id", "name", "score", "data"}) {
+		t.Errorf("unexpected columns: %v", got)
+	}
+
+	got := [][]driver.Value{}
+	for {
+		dest := make([]driver.Value, 4)
+		if err := rows.Next(dest); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("failed to read row: %v", err)
+			}
+			break
+		}
+		got = append(got, dest)
+	}
+	want := [][]driver.Value{
+		{int64(1), "a", 1.5, []byte{1, 2}},
+		{int64(2), nil, float64(2), nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected rows %v, got %v", want, got)
+	}
+
+	requests := server.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	wantQueries := []nsqlitehttp.Query{{
+		Query:  "SELECT id, name, score, data FROM t WHERE id > :id",
+		Params: []nsqlitehttp.QueryParam{{Name: "id", Value: float64(0)}},
+		TxID:   "tx1",
+	}}
+	if !reflect.DeepEqual(requests[0].queries, wantQueries) {
+		t.Errorf("expected queries %+v, got %+v", wantQueries, requests[0].queries)
+	}
+}
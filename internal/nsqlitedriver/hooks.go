@@ -0,0 +1,35 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// hook runs fn between the Before and After hooks of the connector. A Before
+// hook returning an error aborts the operation before fn is called.
+func (c *Conn) hook(ctx context.Context, event *nsqlitehttp.HookEvent, fn func(ctx context.Context) error) error {
+	ctx, err := c.config.hooks.Before(ctx, event)
+
+	start := time.Now()
+	if err == nil {
+		err = fn(ctx)
+	}
+	event.Duration = time.Since(start)
+	event.Err = err
+	c.config.hooks.After(ctx, event)
+
+	return err
+}
+
+// checkQueries returns an error if any of the queries is not allowed in the
+// current state of the connection.
+func (c *Conn) checkQueries(queries []nsqlitehttp.Query) error {
+	for _, query := range queries {
+		if err := c.checkQuery(query.Query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// opsHook records the operations it observes and vetoes the queries
+// containing "forbidden".
+type opsHook struct {
+	ops []string
+}
+
+func (h *opsHook) Before(ctx context.Context, event *nsqlitehttp.HookEvent) (context.Context, error) {
+	for _, q := range event.Queries {
+		if strings.Contains(q.Query, "forbidden") {
+			return ctx, errors.New("vetoed")
+		}
+	}
+	return ctx, nil
+}
+
+func (h *opsHook) After(_ context.Context, event *nsqlitehttp.HookEvent) {
+	op := string(event.Op) + ":" + event.TxID
+	if event.Err != nil {
+		op += ":error"
+	}
+	h.ops = append(h.ops, op)
+}
+
+func TestConnHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries := []nsqlitehttp.Query{}
+		json.NewDecoder(r.Body).Decode(&queries)
+
+		switch query := queries[0].Query; {
+		case strings.HasPrefix(query, "BEGIN"):
+			w.Write([]byte(`{"results":[{"type":"begin","txId":"tx1"}]}`))
+		case strings.HasPrefix(query, "SELECT"):
+			w.Write([]byte(`{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],"rows":[[1]]}]}`))
+		default:
+			w.Write([]byte(`{"results":[{"type":"write","rowsAffected":1}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := nsqlitehttp.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	hook := &opsHook{}
+	driverConn, err := NewConnector(client, WithHooks(hook)).Connect(context.Background())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	conn := driverConn.(*Conn)
+	ctx := context.Background()
+
+	if _, err := conn.BeginTx(ctx, driver.TxOptions{}); err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM t", nil); err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM forbidden", nil); err == nil {
+		t.Fatalf("expected the hook to veto the statement")
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT 1", nil)
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if len(hook.ops) != 3 {
+		t.Errorf("expected the query hook to wait for the rows to be closed, got: %v", hook.ops)
+	}
+	rows.Close()
+
+	if err := conn.CommitTx(ctx); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	want := "begin:tx1,exec:tx1,exec:tx1:error,query:tx1,commit:tx1"
+	if got := strings.Join(hook.ops, ","); got != want {
+		t.Errorf("expected ops %q, got %q", want, got)
+	}
+}
+
+func TestConnHooksNestedTx(t *testing.T) {
+	server := newFakeServer(t, txResponder)
+	hook := &opsHook{}
+	conn := server.connect(t, WithHooks(hook))
+	ctx := context.Background()
+
+	if _, err := conn.BeginTx(ctx, driver.TxOptions{}); err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	nested, err := conn.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("failed to begin nested transaction: %v", err)
+	}
+	if err := nested.Commit(); err != nil {
+		t.Fatalf("failed to commit nested transaction: %v", err)
+	}
+	nested, err = conn.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("failed to begin nested transaction: %v", err)
+	}
+	if err := nested.Rollback(); err != nil {
+		t.Fatalf("failed to rollback nested transaction: %v", err)
+	}
+	if err := conn.CommitTx(ctx); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	want := "begin:tx1,begin:tx1,commit:tx1,begin:tx1,rollback:tx1,commit:tx1"
+	if got := strings.Join(hook.ops, ","); got != want {
+		t.Errorf("expected ops %q, got %q", want, got)
+	}
+}
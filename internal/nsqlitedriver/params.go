@@ -0,0 +1,306 @@
+package nsqlitedriver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/internal/nsqlitelex"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// TimeFormat selects how time.Time parameters are sent to the server.
+type TimeFormat int
+
+const (
+	// TimeFormatText sends times as UTC text using the layout set with
+	// WithParamTimeLayout. This is the default.
+	TimeFormatText TimeFormat = iota
+	// TimeFormatUnix sends times as the number of seconds since the Unix
+	// epoch.
+	TimeFormatUnix
+	// TimeFormatJulian sends times as a fractional Julian day number.
+	TimeFormatJulian
+)
+
+// DefaultParamTimeLayout is the layout used to send time.Time parameters with
+// TimeFormatText, it is understood by the SQLite date and time functions.
+const DefaultParamTimeLayout = "2006-01-02 15:04:05.999999999"
+
+// ErrBlobParam is returned for []byte query arguments. The NSQLite HTTP API
+// has no way to bind BLOB parameters, a []byte would be stored as base64
+// text, so send the value hex encoded and decode it with unhex(?) instead.
+var ErrBlobParam = errors.New("BLOB parameters are not supported, use unhex(?) with a hex string")
+
+// ParamEncoder converts query arguments before they are sent to the server.
+//
+// EncodeParam receives the argument as passed to database/sql, before calling
+// driver.Valuer, and returns the value to send. It returns driver.ErrSkip to
+// leave the argument to the default encoding.
+type ParamEncoder interface {
+	EncodeParam(value any) (any, error)
+}
+
+// ParamEncoderFunc is a function that implements ParamEncoder.
+type ParamEncoderFunc func(value any) (any, error)
+
+// EncodeParam calls f(value).
+func (f ParamEncoderFunc) EncodeParam(value any) (any, error) {
+	return f(value)
+}
+
+// encodeParam converts a query argument to a value that can be sent to the
+// server.
+//
+// The result is one of nil, int64, float64, bool, string or whatever the
+// configured ParamEncoder returns, []byte values are rejected with
+// ErrBlobParam.
+func (c *config) encodeParam(value any) (any, error) {
+	if c.paramEncoder != nil {
+		encoded, err := c.paramEncoder.EncodeParam(value)
+		if !errors.Is(err, driver.ErrSkip) {
+			if _, ok := encoded.([]byte); ok && err == nil {
+				return nil, ErrBlobParam
+			}
+			return encoded, err
+		}
+	}
+
+	switch v := value.(type) {
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, fmt.Errorf("uint64 value %d overflows int64", v)
+		}
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return nil, fmt.Errorf("uint value %d overflows int64", v)
+		}
+	}
+
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := converted.(type) {
+	case []byte:
+		return nil, ErrBlobParam
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("float value %v is not supported", v)
+		}
+	case time.Time:
+		return c.encodeTime(v), nil
+	}
+
+	return converted, nil
+}
+
+// encodeTime converts a time.Time argument using the configured time format.
+func (c *config) encodeTime(t time.Time) any {
+	switch c.paramTimeFormat {
+	case TimeFormatUnix:
+		return t.Unix()
+	case TimeFormatJulian:
+		return float64(t.UnixNano())/float64(24*time.Hour) + julianDayUnixEpoch
+	}
+
+	return t.UTC().Format(c.paramTimeLayout)
+}
+
+// CheckNamedValue converts the arguments of the statements executed by the
+// connection, see ParamEncoder.
+func (c *Conn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := c.config.encodeParam(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = value
+	return nil
+}
+
+// CheckNamedValue converts the arguments of the statement, see ParamEncoder.
+func (s *Stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(nv)
+}
+
+// parsedQuery is a query split into statements with their placeholders.
+type parsedQuery struct {
+	// text is the original query.
+	text string
+	// statements are the statements of the query, each one is sent to the
+	// server as a separate query of the same batch.
+	statements []parsedStatement
+	// params are the placeholders of every statement combined.
+	params nsqlitelex.Params
+}
+
+// parsedStatement is a single statement of a parsedQuery.
+type parsedStatement struct {
+	text   string
+	params nsqlitelex.Params
+}
+
+// parseQuery splits the query into statements and returns their placeholders.
+//
+// Positional placeholders are numbered per statement, so the arguments are
+// consumed in order by each statement. Named arguments are shared by every
+// statement that uses the name.
+//
+// A query with a transaction statement, such as "BEGIN; ...; COMMIT", is not
+// split: the statements of a batch are not sent in the same transaction, so
+// the server must receive the script as written.
+func parseQuery(query string) (*parsedQuery, error) {
+	tokens, err := nsqlitelex.Tokenize(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	parsed := &parsedQuery{text: query}
+	seenNames := map[string]bool{}
+	split := true
+
+	for _, stmt := range nsqlitelex.SplitStatements(tokens) {
+		if stmt.IsTransactionControl() {
+			split = false
+		}
+
+		params, err := nsqlitelex.ParseParams(stmt.Tokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+
+		parsed.statements = append(parsed.statements, parsedStatement{
+			text:   stmt.Text,
+			params: params,
+		})
+
+		if params.Positional {
+			parsed.params.Positional = true
+			parsed.params.Count += params.Count
+		}
+		for _, name := range params.Names {
+			if !seenNames[name] {
+				seenNames[name] = true
+				parsed.params.Names = append(parsed.params.Names, name)
+				parsed.params.Count++
+			}
+		}
+	}
+
+	if parsed.params.Positional && parsed.params.Named() {
+		return nil, fmt.Errorf(
+			"invalid query: statements mix positional and named placeholders",
+		)
+	}
+
+	// A single statement, a query without code or a script with transaction
+	// statements is sent untouched. The server binds the arguments once for
+	// the whole query, so ?NNN and named placeholders are shared by its
+	// statements.
+	if len(parsed.statements) <= 1 || !split {
+		params, err := nsqlitelex.ParseParams(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		parsed.params = params
+		parsed.statements = []parsedStatement{{
+			text:   query,
+			params: parsed.params,
+		}}
+	}
+
+	return parsed, nil
+}
+
+// queries returns the queries to send to the server for the given arguments,
+// one per statement. The arguments must have been checked with checkArgs.
+func (p *parsedQuery) queries(args []driver.NamedValue, txID string) []nsqlitehttp.Query {
+	queries := make([]nsqlitehttp.Query, len(p.statements))
+
+	next := 0
+	for i, stmt := range p.statements {
+		stmtArgs := args
+		if len(p.statements) > 1 {
+			stmtArgs = statementArgs(stmt.params, args, &next)
+		}
+
+		queries[i] = nsqlitehttp.Query{
+			Query:  stmt.text,
+			Params: convertNamedValueToQueryParam(stmtArgs),
+			TxID:   txID,
+		}
+	}
+
+	return queries
+}
+
+// statementArgs returns the arguments used by a statement, next is the index
+// of the first positional argument not consumed yet.
+func statementArgs(params nsqlitelex.Params, args []driver.NamedValue, next *int) []driver.NamedValue {
+	if !params.Named() {
+		end := min(*next+params.Count, len(args))
+		stmtArgs := args[*next:end]
+		*next = end
+		return stmtArgs
+	}
+
+	names := params.ArgNames()
+	stmtArgs := []driver.NamedValue{}
+	for _, arg := range args {
+		if slices.Contains(names, arg.Name) {
+			stmtArgs = append(stmtArgs, arg)
+		}
+	}
+	return stmtArgs
+}
+
+// checkArgs checks that the arguments match the placeholders of the query.
+//
+// Named placeholders require every argument to be named (sql.Named) and every
+// name to be provided, positional placeholders require as many unnamed
+// arguments as placeholders.
+func checkArgs(params nsqlitelex.Params, args []driver.NamedValue) error {
+	if !params.Named() {
+		for _, arg := range args {
+			if arg.Name != "" {
+				return fmt.Errorf(
+					"named argument %q used in a query with positional placeholders",
+					arg.Name,
+				)
+			}
+		}
+		if len(args) != params.Count {
+			return fmt.Errorf("expected %d arguments, got %d", params.Count, len(args))
+		}
+		return nil
+	}
+
+	provided := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if arg.Name == "" {
+			return fmt.Errorf(
+				"argument %d has no name but the query uses named placeholders, use sql.Named",
+				arg.Ordinal,
+			)
+		}
+		provided[arg.Name] = true
+	}
+
+	for _, name := range params.ArgNames() {
+		if !provided[name] {
+			return fmt.Errorf("missing named argument %q", name)
+		}
+		delete(provided, name)
+	}
+	for _, arg := range args {
+		if provided[arg.Name] {
+			return fmt.Errorf("named argument %q is not used by the query", arg.Name)
+		}
+	}
+
+	return nil
+}
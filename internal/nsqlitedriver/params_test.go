@@ -0,0 +1,349 @@
+package nsqlitedriver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// celsius is a custom type used to test the driver.Valuer handling.
+type celsius float64
+
+func (c celsius) Value() (driver.Value, error) {
+	return float64(c), nil
+}
+
+// point is a custom type only known by a custom ParamEncoder.
+type point struct{ x, y int }
+
+func TestEncodeParam(t *testing.T) {
+	when := time.Date(1970, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))
+	var nilValuer *celsius
+
+	withConfig := func(opts ...ConnectorOption) *config {
+		c := newConfig()
+		for _, opt := range opts {
+			opt(c)
+		}
+		return c
+	}
+
+	encoder := ParamEncoderFunc(func(value any) (any, error) {
+		if p, ok := value.(point); ok {
+			return [2]int{p.x, p.y}, nil
+		}
+		return nil, driver.ErrSkip
+	})
+
+	tests := []struct {
+		name        string
+		config      *config
+		value       any
+		want        any
+		expectError bool
+	}{
+		{name: "Nil", config: newConfig(), value: nil, want: nil},
+		{name: "Int", config: newConfig(), value: 42, want: int64(42)},
+		{name: "Uint32", config: newConfig(), value: uint32(42), want: int64(42)},
+		{
+			name:   "Uint64 in range",
+			config: newConfig(),
+			value:  uint64(math.MaxInt64),
+			want:   int64(math.MaxInt64),
+		},
+		{
+			name:        "Uint64 out of range",
+			config:      newConfig(),
+			value:       uint64(math.MaxInt64) + 1,
+			expectError: true,
+		},
+		{name: "Float32", config: newConfig(), value: float32(1.5), want: 1.5},
+		{name: "NaN", config: newConfig(), value: math.NaN(), expectError: true},
+		{name: "String", config: newConfig(), value: "text", want: "text"},
+		{name: "Bytes", config: newConfig(), value: []byte("blob"), expectError: true},
+		{name: "Valuer", config: newConfig(), value: celsius(21.5), want: 21.5},
+		{name: "Nil valuer", config: newConfig(), value: nilValuer, want: nil},
+		{name: "Pointer", config: newConfig(), value: &[]int{7}[0], want: int64(7)},
+		{
+			name:   "Time as text",
+			config: newConfig(),
+			value:  when,
+			want:   "1970-01-02 02:04:05",
+		},
+		{
+			name:   "Time with custom layout",
+			config: withConfig(WithParamTimeLayout(time.RFC3339)),
+			value:  when,
+			want:   "1970-01-02T02:04:05Z",
+		},
+		{
+			name:   "Time as unix",
+			config: withConfig(WithParamTimeFormat(TimeFormatUnix)),
+			value:  when,
+			want:   int64(93845),
+		},
+		{
+			name:   "Time as julian day",
+			config: withConfig(WithParamTimeFormat(TimeFormatJulian)),
+			value:  time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC),
+			want:   2440588.5,
+		},
+		{
+			name:        "Unsupported type",
+			config:      newConfig(),
+			value:       point{1, 2},
+			expectError: true,
+		},
+		{
+			name:   "Custom encoder",
+			config: withConfig(WithParamEncoder(encoder)),
+			value:  point{1, 2},
+			want:   [2]int{1, 2},
+		},
+		{
+			name:   "Custom encoder skips",
+			config: withConfig(WithParamEncoder(encoder)),
+			value:  int8(3),
+			want:   int64(3),
+		},
+		{
+			name: "Custom encoder returns bytes",
+			config: withConfig(WithParamEncoder(ParamEncoderFunc(func(any) (any, error) {
+				return []byte("blob"), nil
+			}))),
+			value:       1,
+			expectError: true,
+		},
+		{
+			name: "Custom encoder error",
+			config: withConfig(WithParamEncoder(ParamEncoderFunc(func(any) (any, error) {
+				return nil, errors.New("boom")
+			}))),
+			value:       1,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.encodeParam(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("encodeParam() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		args        []driver.NamedValue
+		expectError bool
+	}{
+		{
+			name:  "Positional arguments",
+			query: "SELECT ?, ?",
+			args:  []driver.NamedValue{{Ordinal: 1}, {Ordinal: 2}},
+		},
+		{
+			name:        "Wrong number of positional arguments",
+			query:       "SELECT ?, ?",
+			args:        []driver.NamedValue{{Ordinal: 1}},
+			expectError: true,
+		},
+		{
+			name:        "Named argument with positional placeholders",
+			query:       "SELECT ?",
+			args:        []driver.NamedValue{{Ordinal: 1, Name: "id"}},
+			expectError: true,
+		},
+		{
+			name:  "Named arguments",
+			query: "SELECT :id, @name, :id",
+			args:  []driver.NamedValue{{Ordinal: 1, Name: "name"}, {Ordinal: 2, Name: "id"}},
+		},
+		{
+			name:  "Named argument with several prefixes",
+			query: "SELECT :id, @id, $id",
+			args:  []driver.NamedValue{{Ordinal: 1, Name: "id"}},
+		},
+		{
+			name:        "Missing named argument",
+			query:       "SELECT :id, :name",
+			args:        []driver.NamedValue{{Ordinal: 1, Name: "id"}},
+			expectError: true,
+		},
+		{
+			name:        "Unnamed argument with named placeholders",
+			query:       "SELECT :id",
+			args:        []driver.NamedValue{{Ordinal: 1}},
+			expectError: true,
+		},
+		{
+			name:        "Unused named argument",
+			query:       "SELECT :id",
+			args:        []driver.NamedValue{{Ordinal: 1, Name: "id"}, {Ordinal: 2, Name: "other"}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = checkArgs(parsed.params, tt.args)
+			if tt.expectError && err == nil {
+				t.Errorf("expected an error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("did not expect an error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsedQueryQueries(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		args      []driver.NamedValue
+		wantCount int
+		want      []nsqlitehttp.Query
+	}{
+		{
+			name:      "Single statement is sent untouched",
+			query:     "SELECT ?; ",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			wantCount: 1,
+			want: []nsqlitehttp.Query{{
+				Query:  "SELECT ?; ",
+				Params: []nsqlitehttp.QueryParam{{Value: int64(1)}},
+				TxID:   "tx",
+			}},
+		},
+		{
+			name:  "Positional arguments are consumed in order",
+			query: "SELECT ?, ?; SELECT 1; SELECT ?",
+			args: []driver.NamedValue{
+				{Ordinal: 1, Value: int64(1)},
+				{Ordinal: 2, Value: int64(2)},
+				{Ordinal: 3, Value: int64(3)},
+			},
+			wantCount: 3,
+			want: []nsqlitehttp.Query{
+				{
+					Query:  "SELECT ?, ?",
+					Params: []nsqlitehttp.QueryParam{{Value: int64(1)}, {Value: int64(2)}},
+					TxID:   "tx",
+				},
+				{Query: "SELECT 1", Params: []nsqlitehttp.QueryParam{}, TxID: "tx"},
+				{
+					Query:  "SELECT ?",
+					Params: []nsqlitehttp.QueryParam{{Value: int64(3)}},
+					TxID:   "tx",
+				},
+			},
+		},
+		{
+			name:      "Script with transaction statements is sent untouched",
+			query:     "BEGIN; INSERT INTO t VALUES (?); COMMIT;",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			wantCount: 1,
+			want: []nsqlitehttp.Query{{
+				Query:  "BEGIN; INSERT INTO t VALUES (?); COMMIT;",
+				Params: []nsqlitehttp.QueryParam{{Value: int64(1)}},
+				TxID:   "tx",
+			}},
+		},
+		{
+			name:      "Script with transaction statements shares numbered placeholders",
+			query:     "BEGIN; INSERT INTO t VALUES (?1); INSERT INTO t VALUES (?1); COMMIT",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			wantCount: 1,
+			want: []nsqlitehttp.Query{{
+				Query:  "BEGIN; INSERT INTO t VALUES (?1); INSERT INTO t VALUES (?1); COMMIT",
+				Params: []nsqlitehttp.QueryParam{{Value: int64(1)}},
+				TxID:   "tx",
+			}},
+		},
+		{
+			name:      "Named argument binds every prefix",
+			query:     "SELECT :a, @a",
+			args:      []driver.NamedValue{{Ordinal: 1, Name: "a", Value: int64(1)}},
+			wantCount: 2,
+			want: []nsqlitehttp.Query{{
+				Query:  "SELECT :a, @a",
+				Params: []nsqlitehttp.QueryParam{{Name: "a", Value: int64(1)}},
+				TxID:   "tx",
+			}},
+		},
+		{
+			name:  "Named arguments are shared",
+			query: "SELECT :a; SELECT :a, :b",
+			args: []driver.NamedValue{
+				{Ordinal: 1, Name: "a", Value: int64(1)},
+				{Ordinal: 2, Name: "b", Value: int64(2)},
+			},
+			wantCount: 2,
+			want: []nsqlitehttp.Query{
+				{
+					Query:  "SELECT :a",
+					Params: []nsqlitehttp.QueryParam{{Name: "a", Value: int64(1)}},
+					TxID:   "tx",
+				},
+				{
+					Query: "SELECT :a, :b",
+					Params: []nsqlitehttp.QueryParam{
+						{Name: "a", Value: int64(1)},
+						{Name: "b", Value: int64(2)},
+					},
+					TxID: "tx",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.params.Count != tt.wantCount {
+				t.Errorf("expected %d params, got %d", tt.wantCount, parsed.params.Count)
+			}
+			if err := checkArgs(parsed.params, tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := parsed.queries(tt.args, "tx")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseQueryRejectsMixedStatements(t *testing.T) {
+	if _, err := parseQuery("SELECT ?; SELECT :a"); err == nil {
+		t.Errorf("expected an error but got none")
+	}
+}
@@ -0,0 +1,195 @@
+package nsqlitedriver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+var (
+	_ driver.Rows                           = (*QueryRows)(nil)
+	_ driver.RowsNextResultSet              = (*QueryRows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*QueryRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*QueryRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*QueryRows)(nil)
+	_ driver.RowsColumnTypeLength           = (*QueryRows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*QueryRows)(nil)
+)
+
+// QueryRows represents a set of query results.
+//
+// Rows are read lazily from the response body of the server, so memory
+// usage does not depend on the size of the result set.
+//
+// A query made of several statements returns one result set per statement,
+// they are iterated with sql.Rows.NextResultSet.
+type QueryRows struct {
+	stream      *nsqlitehttp.QueryStream
+	config      *config
+	columns     []string
+	types       []string
+	columnTypes []columnType
+
+	// resultIdx is the index of the current result set.
+	resultIdx int
+	// resultsLen is the number of result sets, one per statement sent.
+	resultsLen int
+
+	// responses are the responses of the result sets read so far, without
+	// their rows.
+	responses []nsqlitehttp.QueryResponse
+	// err is the first error found while reading the results.
+	err error
+	// onClose is called once the rows are closed, if set.
+	onClose func(responses []nsqlitehttp.QueryResponse, err error)
+}
+
+// newQueryRows creates the rows of a response with the given number of
+// results, positioned at its first result.
+func newQueryRows(stream *nsqlitehttp.QueryStream, conf *config, resultsLen int) (*QueryRows, error) {
+	r := &QueryRows{
+		stream:     stream,
+		config:     conf,
+		resultIdx:  -1,
+		resultsLen: resultsLen,
+	}
+
+	if err := r.NextResultSet(); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("failed to execute query: empty response")
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Columns returns the column names.
+func (r *QueryRows) Columns() []string {
+	return r.columns
+}
+
+// Close releases resources associated with the rows, discarding the unread
+// part of the response.
+func (r *QueryRows) Close() error {
+	err := r.stream.Close()
+	if r.onClose != nil {
+		r.onClose(r.responses, r.err)
+		r.onClose = nil
+	}
+	return err
+}
+
+// setErr records err if no error was found before.
+func (r *QueryRows) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Next prepares the next row for reading.
+func (r *QueryRows) Next(dest []driver.Value) error {
+	row, err := r.stream.NextRow()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			r.setErr(err)
+		}
+		return err
+	}
+	if len(row) != len(dest) {
+		return fmt.Errorf(
+			"row has %d values but %d columns were expected", len(row), len(dest),
+		)
+	}
+
+	for i, val := range row {
+		dest[i] = r.config.convertValue(val, r.columnType(i))
+	}
+
+	return nil
+}
+
+// HasNextResultSet reports whether there is another result set after the
+// current one.
+func (r *QueryRows) HasNextResultSet() bool {
+	return r.resultIdx+1 < r.resultsLen
+}
+
+// NextResultSet advances to the next result set, discarding the unread rows
+// of the current one. It returns io.EOF when there are no more result sets
+// and the error of the statement if it failed.
+func (r *QueryRows) NextResultSet() error {
+	resp, err := r.stream.NextResult()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			r.setErr(err)
+		}
+		return err
+	}
+	r.resultIdx++
+	r.responses = append(r.responses, *resp)
+
+	if err := resp.Err(); err != nil {
+		err = fmt.Errorf("failed to execute query: %w", err)
+		r.setErr(err)
+		return err
+	}
+
+	r.columns = resp.Columns
+	r.types = resp.Types
+	r.columnTypes = make([]columnType, len(resp.Types))
+	for i, declared := range resp.Types {
+		r.columnTypes[i] = parseColumnType(declared)
+	}
+
+	return nil
+}
+
+// columnType returns the parsed declared type of the column.
+func (r *QueryRows) columnType(index int) columnType {
+	if index < 0 || index >= len(r.columnTypes) {
+		return columnType{}
+	}
+	return r.columnTypes[index]
+}
+
+// ColumnTypeDatabaseTypeName returns the database type name for the column.
+func (r *QueryRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < 0 {
+		return ""
+	}
+	if index >= len(r.types) {
+		return ""
+	}
+	return strings.ToUpper(r.types[index])
+}
+
+// ColumnTypeScanType returns the Go type of the values of the column, derived
+// from its declared type and the value mode of the connector.
+func (r *QueryRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.columnType(index).scanType(r.config.valueMode)
+}
+
+// ColumnTypeNullable reports whether the column may be NULL. The server does
+// not send the column constraints so the nullability is always unknown.
+func (r *QueryRows) ColumnTypeNullable(_ int) (nullable, ok bool) {
+	return false, false
+}
+
+// ColumnTypeLength returns the length of text and binary columns, such as 255
+// for "VARCHAR(255)". Columns without a declared length, such as TEXT or BLOB,
+// return math.MaxInt64.
+func (r *QueryRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	return r.columnType(index).length()
+}
+
+// ColumnTypePrecisionScale returns the precision and scale of decimal columns,
+// such as 10 and 2 for "DECIMAL(10,2)".
+func (r *QueryRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	return r.columnType(index).precisionScale()
+}
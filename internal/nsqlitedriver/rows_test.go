@@ -0,0 +1,79 @@
+package nsqlitedriver
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+func TestQueryRowsNextResultSet(t *testing.T) {
+	server := newFakeServer(t, func([]nsqlitehttp.Query) string {
+		return `{"results":[` +
+			`{"type":"read","columns":["id"],"types":["INTEGER"],"rows":[[1],[2]]},` +
+			`{"type":"read","columns":["name"],"types":["TEXT"],"rows":[["a"]]}]}`
+	})
+	client, err := nsqlitehttp.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	db := sql.OpenDB(NewConnector(client))
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id FROM t WHERE id > ?; SELECT name FROM u", 0)
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed to scan: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("expected ids [1 2], got %v", ids)
+	}
+
+	if !rows.NextResultSet() {
+		t.Fatalf("expected a second result set, got error: %v", rows.Err())
+	}
+	columns, err := rows.Columns()
+	if err != nil || !reflect.DeepEqual(columns, []string{"name"}) {
+		t.Errorf("expected the columns of the second result set, got %v (%v)", columns, err)
+	}
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("failed to scan: %v", err)
+		}
+		names = append(names, name)
+	}
+	if !reflect.DeepEqual(names, []string{"a"}) {
+		t.Errorf("expected names [a], got %v", names)
+	}
+
+	if rows.NextResultSet() {
+		t.Errorf("expected no third result set")
+	}
+	if err := rows.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	requests := server.received()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	want := []nsqlitehttp.Query{
+		{Query: "SELECT id FROM t WHERE id > ?", Params: []nsqlitehttp.QueryParam{{Value: float64(0)}}},
+		{Query: "SELECT name FROM u"},
+	}
+	if !reflect.DeepEqual(requests[0].queries, want) {
+		t.Errorf("expected the statements %+v, got %+v", want, requests[0].queries)
+	}
+}
@@ -0,0 +1,134 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// ErrSavepointNotActive is returned when releasing or rolling back to a
+// savepoint that does not exist in the current transaction.
+var ErrSavepointNotActive = errors.New("savepoint is not active")
+
+// savepoint is an active savepoint of the current transaction.
+type savepoint struct {
+	// name is the name of the savepoint.
+	name string
+	// prevReadOnly is the read-only state of the transaction before the
+	// savepoint was created, restored when the savepoint ends.
+	prevReadOnly bool
+}
+
+// Savepoint creates a savepoint with the given name in the current
+// transaction.
+//
+// It is available from database/sql through sql.Conn.Raw. Savepoint
+// statements are sent between the hooks of the connector, as HookOpBegin,
+// HookOpCommit for the release and HookOpRollback.
+func (c *Conn) Savepoint(ctx context.Context, name string) error {
+	if c.txID == "" {
+		return errors.New("savepoints require an active transaction")
+	}
+	if name == "" {
+		return errors.New("savepoint name is required")
+	}
+
+	if _, err := c.sendTxQuery(ctx, nsqlitehttp.HookOpBegin, savepointQuery("SAVEPOINT", name)); err != nil {
+		return err
+	}
+
+	c.savepoints = append(c.savepoints, savepoint{
+		name:         name,
+		prevReadOnly: c.txReadOnly,
+	})
+	return nil
+}
+
+// ReleaseSavepoint releases the savepoint with the given name and every
+// savepoint created after it.
+func (c *Conn) ReleaseSavepoint(ctx context.Context, name string) error {
+	idx := c.savepointIndex(name)
+	if idx < 0 {
+		return fmt.Errorf("%w: %q", ErrSavepointNotActive, name)
+	}
+
+	if _, err := c.sendTxQuery(ctx, nsqlitehttp.HookOpCommit, savepointQuery("RELEASE", name)); err != nil {
+		return err
+	}
+
+	c.txReadOnly = c.savepoints[idx].prevReadOnly
+	c.savepoints = c.savepoints[:idx]
+	return nil
+}
+
+// RollbackToSavepoint reverts the current transaction to the savepoint with
+// the given name. The savepoint stays active but every savepoint created after
+// it is removed.
+func (c *Conn) RollbackToSavepoint(ctx context.Context, name string) error {
+	idx := c.savepointIndex(name)
+	if idx < 0 {
+		return fmt.Errorf("%w: %q", ErrSavepointNotActive, name)
+	}
+
+	if _, err := c.sendTxQuery(ctx, nsqlitehttp.HookOpRollback, savepointQuery("ROLLBACK TO", name)); err != nil {
+		return err
+	}
+
+	if idx+1 < len(c.savepoints) {
+		c.txReadOnly = c.savepoints[idx+1].prevReadOnly
+	}
+	c.savepoints = c.savepoints[:idx+1]
+	return nil
+}
+
+// rollbackSavepoint reverts the current transaction to the savepoint with the
+// given name and releases it, in a single request, to roll back a nested
+// transaction.
+func (c *Conn) rollbackSavepoint(ctx context.Context, name string) error {
+	idx := c.savepointIndex(name)
+	if idx < 0 {
+		return fmt.Errorf("%w: %q", ErrSavepointNotActive, name)
+	}
+
+	_, err := c.sendTxQuery(
+		ctx, nsqlitehttp.HookOpRollback,
+		savepointQuery("ROLLBACK TO", name), savepointQuery("RELEASE", name),
+	)
+	if err != nil {
+		return err
+	}
+
+	c.txReadOnly = c.savepoints[idx].prevReadOnly
+	c.savepoints = c.savepoints[:idx]
+	return nil
+}
+
+// savepointQuery returns the savepoint statement with the given command.
+func savepointQuery(command string, name string) string {
+	return command + " " + nsqlitehttp.QuoteIdentifier(name) + ";"
+}
+
+// SavepointDepth returns the number of active savepoints in the current
+// transaction.
+func (c *Conn) SavepointDepth() int {
+	return len(c.savepoints)
+}
+
+// savepointIndex returns the index of the most recent active savepoint with
+// the given name, or -1 if there is none.
+func (c *Conn) savepointIndex(name string) int {
+	for i := len(c.savepoints) - 1; i >= 0; i-- {
+		if c.savepoints[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextSavepointName returns the name of the savepoint used by a nested
+// transaction.
+func (c *Conn) nextSavepointName() string {
+	return fmt.Sprintf("nsqlitego_sp_%d", len(c.savepoints)+1)
+}
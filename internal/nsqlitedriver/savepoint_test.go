@@ -0,0 +1,137 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sentStatements returns the statements received by the server and checks
+// that they were sent in the transaction "tx1", except BEGIN.
+func sentStatements(t *testing.T, server *fakeServer) []string {
+	t.Helper()
+
+	statements := []string{}
+	for _, request := range server.received() {
+		for _, query := range request.queries {
+			if query.Query != "BEGIN DEFERRED;" && query.TxID != "tx1" {
+				t.Errorf("expected %q in the transaction tx1, got %q", query.Query, query.TxID)
+			}
+			statements = append(statements, query.Query)
+		}
+	}
+	return statements
+}
+
+func TestNestedBeginTx(t *testing.T) {
+	server := newFakeServer(t, txResponder)
+	conn := server.connect(t)
+	ctx := context.Background()
+
+	outer, err := conn.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	middle, err := conn.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("failed to begin nested transaction: %v", err)
+	}
+	inner, err := conn.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatalf("failed to begin nested transaction: %v", err)
+	}
+	if depth := conn.SavepointDepth(); depth != 2 {
+		t.Errorf("expected 2 savepoints, got %d", depth)
+	}
+
+	if err := inner.Commit(); err != nil {
+		t.Fatalf("failed to commit nested transaction: %v", err)
+	}
+	if depth := conn.SavepointDepth(); depth != 1 {
+		t.Errorf("expected 1 savepoint after the inner commit, got %d", depth)
+	}
+	if err := middle.Rollback(); err != nil {
+		t.Fatalf("failed to rollback nested transaction: %v", err)
+	}
+	if depth := conn.SavepointDepth(); depth != 0 {
+		t.Errorf("expected no savepoint after the middle rollback, got %d", depth)
+	}
+	if err := outer.Commit(); err != nil {
+		t.Fatalf("failed to commit transaction: %v", err)
+	}
+
+	want := []string{
+		"BEGIN DEFERRED;",
+		`SAVEPOINT "nsqlitego_sp_1";`,
+		`SAVEPOINT "nsqlitego_sp_2";`,
+		`RELEASE "nsqlitego_sp_2";`,
+		`ROLLBACK TO "nsqlitego_sp_1";`,
+		`RELEASE "nsqlitego_sp_1";`,
+		"COMMIT",
+	}
+	if got := sentStatements(t, server); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected statements %q, got %q", want, got)
+	}
+	if conn.txID != "" {
+		t.Errorf("expected the transaction to end, got %q", conn.txID)
+	}
+}
+
+func TestSavepointRestoresReadOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(conn *Conn, tx driver.Tx) error
+		want []string
+	}{
+		{
+			name: "Release",
+			end:  func(_ *Conn, tx driver.Tx) error { return tx.Commit() },
+			want: []string{`RELEASE "nsqlitego_sp_2";`},
+		},
+		{
+			name: "Rollback to an earlier savepoint",
+			end: func(conn *Conn, _ driver.Tx) error {
+				return conn.RollbackToSavepoint(context.Background(), "nsqlitego_sp_1")
+			},
+			want: []string{`ROLLBACK TO "nsqlitego_sp_1";`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, txResponder)
+			conn := server.connect(t)
+			ctx := context.Background()
+
+			if _, err := conn.BeginTx(ctx, driver.TxOptions{}); err != nil {
+				t.Fatalf("failed to begin transaction: %v", err)
+			}
+			if _, err := conn.BeginTx(ctx, driver.TxOptions{}); err != nil {
+				t.Fatalf("failed to begin nested transaction: %v", err)
+			}
+			readOnly, err := conn.BeginTx(ctx, driver.TxOptions{ReadOnly: true})
+			if err != nil {
+				t.Fatalf("failed to begin read-only nested transaction: %v", err)
+			}
+
+			if _, err := conn.ExecContext(ctx, "DELETE FROM t", nil); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("expected ErrReadOnly in the read-only savepoint, got: %v", err)
+			}
+			server.received()
+
+			if err := tt.end(conn, readOnly); err != nil {
+				t.Fatalf("failed to end savepoint: %v", err)
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM t", nil); err != nil {
+				t.Errorf("expected writes to be allowed again, got: %v", err)
+			}
+
+			want := append(tt.want, "DELETE FROM t")
+			if got := sentStatements(t, server); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected statements %q, got %q", want, got)
+			}
+		})
+	}
+}
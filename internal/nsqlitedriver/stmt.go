@@ -3,26 +3,23 @@ package nsqlitedriver
 import (
 	"context"
 	"database/sql/driver"
-	"fmt"
-	"io"
-	"strings"
 
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
 var (
-	_ driver.Stmt                           = (*Stmt)(nil)
-	_ driver.StmtExecContext                = (*Stmt)(nil)
-	_ driver.StmtQueryContext               = (*Stmt)(nil)
-	_ driver.RowsColumnTypeDatabaseTypeName = (*QueryRows)(nil)
+	_ driver.Stmt              = (*Stmt)(nil)
+	_ driver.StmtExecContext   = (*Stmt)(nil)
+	_ driver.StmtQueryContext  = (*Stmt)(nil)
+	_ driver.NamedValueChecker = (*Stmt)(nil)
 )
 
 // Stmt represents a prepared statement.
 type Stmt struct {
 	// conn is the connection associated with the statement.
 	conn *Conn
-	// query is the query string to be executed with NSQLite.
-	query string
+	// parsed is the query to be executed with NSQLite.
+	parsed *parsedQuery
 }
 
 // Close releases resources associated with the statement.
@@ -30,10 +27,10 @@ func (s *Stmt) Close() error {
 	return nil
 }
 
-// NumInput returns the number of placeholder parameters for the statement.
-// -1 indicates that the number is unknown or dynamic.
+// NumInput returns the number of placeholder parameters for the statement,
+// database/sql uses it to check the number of arguments before executing it.
 func (s *Stmt) NumInput() int {
-	return -1
+	return s.parsed.params.Count
 }
 
 // ExecResult represents the result of a query.
@@ -54,22 +51,7 @@ func (r *ExecResult) RowsAffected() (int64, error) {
 
 // ExecContext executes a query without returning rows (e.g., INSERT, UPDATE).
 func (s *Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
-	params := convertNamedValueToQueryParam(args)
-	resp, err := s.conn.client.SendQuery(ctx, nsqlitehttp.Query{
-		Query:  s.query,
-		Params: params,
-		TxID:   s.conn.txID,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
-	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("failed to execute query: %s", resp.Error)
-	}
-	return &ExecResult{
-		lastInsertId: resp.LastInsertID,
-		rowsAffected: resp.RowsAffected,
-	}, nil
+	return s.conn.exec(ctx, s.parsed, args)
 }
 
 // Exec executes a query without returning rows (e.g., INSERT, UPDATE, DELETE).
@@ -77,72 +59,9 @@ func (s *Stmt) Exec(args []driver.Value) (driver.Result, error) {
 	return s.ExecContext(context.Background(), convertValueToNamedValue(args))
 }
 
-// QueryRows represents a set of query results.
-type QueryRows struct {
-	columns []string
-	types   []string
-	rows    [][]any
-	rowsLen int
-	rowIdx  int
-}
-
-// Columns returns the column names.
-func (r *QueryRows) Columns() []string {
-	return r.columns
-}
-
-// Close releases resources associated with the rows.
-func (r *QueryRows) Close() error {
-	return nil
-}
-
-// Next prepares the next row for reading.
-func (r *QueryRows) Next(dest []driver.Value) error {
-	if r.rowIdx >= r.rowsLen {
-		return io.EOF
-	}
-
-	row := r.rows[r.rowIdx]
-	for i, val := range row {
-		dest[i] = val
-	}
-
-	r.rowIdx++
-	return nil
-}
-
-// ColumnTypeDatabaseTypeName returns the database type name for the column.
-func (r *QueryRows) ColumnTypeDatabaseTypeName(index int) string {
-	if index < 0 {
-		return ""
-	}
-	if index >= len(r.types) {
-		return ""
-	}
-	return strings.ToUpper(r.types[index])
-}
-
 // QueryContext executes a query that returns rows (e.g., SELECT).
 func (s *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
-	params := convertNamedValueToQueryParam(args)
-	resp, err := s.conn.client.SendQuery(ctx, nsqlitehttp.Query{
-		Query:  s.query,
-		Params: params,
-		TxID:   s.conn.txID,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
-	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("failed to execute query: %s", resp.Error)
-	}
-	return &QueryRows{
-		columns: resp.Columns,
-		types:   resp.Types,
-		rows:    resp.Rows,
-		rowsLen: len(resp.Rows),
-		rowIdx:  0,
-	}, nil
+	return s.conn.query(ctx, s.parsed, args)
 }
 
 // Query executes a query that returns rows (e.g., SELECT).
@@ -2,23 +2,144 @@ package nsqlitedriver
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
 )
 
 var _ driver.Tx = (*Tx)(nil)
 
+// ErrReadOnly is returned when a write statement is executed in read-only
+// mode, the statement is rejected before reaching the server.
+var ErrReadOnly = errors.New("write statement not allowed in read-only mode")
+
+// TxBeginMode is the SQLite transaction behavior used to start a transaction.
+//
+// https://www.sqlite.org/lang_transaction.html
+type TxBeginMode string
+
+const (
+	// TxBeginDeferred does not acquire any lock until the database is first
+	// accessed. This is the SQLite default.
+	TxBeginDeferred TxBeginMode = "DEFERRED"
+	// TxBeginImmediate starts a write transaction right away.
+	TxBeginImmediate TxBeginMode = "IMMEDIATE"
+	// TxBeginExclusive starts a write transaction right away and prevents
+	// other connections from reading while it is active.
+	TxBeginExclusive TxBeginMode = "EXCLUSIVE"
+)
+
+// txBeginModeKey is the context key for the TxBeginMode.
+type txBeginModeKey struct{}
+
+// WithTxBeginMode returns a copy of ctx that makes BeginTx start the
+// transaction using the given mode when sql.TxOptions.Isolation is
+// sql.LevelDefault.
+func WithTxBeginMode(ctx context.Context, mode TxBeginMode) context.Context {
+	return context.WithValue(ctx, txBeginModeKey{}, mode)
+}
+
+// txBeginModeFromContext returns the TxBeginMode stored in ctx, if any.
+func txBeginModeFromContext(ctx context.Context) (TxBeginMode, bool) {
+	mode, ok := ctx.Value(txBeginModeKey{}).(TxBeginMode)
+	return mode, ok
+}
+
+// beginQuery returns the BEGIN statement that honors the given options.
+//
+// SQLite transactions are always serializable, so every isolation level up to
+// sql.LevelSerializable is satisfied. The level only decides when the locks
+// are taken:
+//
+//   - sql.LevelDefault uses the mode from WithTxBeginMode or defaultMode,
+//     read-only transactions use DEFERRED instead of defaultMode.
+//   - sql.LevelReadUncommitted, sql.LevelReadCommitted,
+//     sql.LevelRepeatableRead and sql.LevelSnapshot use DEFERRED.
+//   - sql.LevelSerializable uses IMMEDIATE so writes can not fail upgrading
+//     their lock.
+//   - sql.LevelLinearizable uses EXCLUSIVE.
+//
+// Read-only transactions never need a write lock so IMMEDIATE is downgraded
+// to DEFERRED for them.
+func beginQuery(ctx context.Context, opts driver.TxOptions, defaultMode TxBeginMode) (string, error) {
+	mode := TxBeginDeferred
+
+	switch level := sql.IsolationLevel(opts.Isolation); level {
+	case sql.LevelDefault:
+		ctxMode, ok := txBeginModeFromContext(ctx)
+		if !ok {
+			// Read-only transactions never need a write lock.
+			if !opts.ReadOnly {
+				mode = defaultMode
+			}
+			break
+		}
+		if opts.ReadOnly && ctxMode != TxBeginDeferred {
+			return "", fmt.Errorf(
+				"read-only transactions can not use BEGIN %s", ctxMode,
+			)
+		}
+		mode = ctxMode
+	case sql.LevelReadUncommitted, sql.LevelReadCommitted,
+		sql.LevelRepeatableRead, sql.LevelSnapshot:
+		mode = TxBeginDeferred
+	case sql.LevelSerializable:
+		mode = TxBeginImmediate
+		if opts.ReadOnly {
+			mode = TxBeginDeferred
+		}
+	case sql.LevelLinearizable:
+		mode = TxBeginExclusive
+	default:
+		return "", fmt.Errorf("isolation level %s is not supported by NSQLite", level)
+	}
+
+	switch mode {
+	case TxBeginDeferred, TxBeginImmediate, TxBeginExclusive:
+	default:
+		return "", fmt.Errorf("unsupported transaction begin mode %q", mode)
+	}
+
+	return "BEGIN " + string(mode) + ";", nil
+}
+
 // Tx represents a transaction.
 type Tx struct {
 	// conn is the connection associated with the transaction.
 	conn *Conn
+	// ctx carries the values of the BeginTx context, such as the request auth
+	// and the span, to the commit and rollback. It is never canceled, so the
+	// transaction can still be rolled back once the BeginTx context is done.
+	ctx context.Context
+	// savepoint is the name of the savepoint backing a nested transaction,
+	// empty for top level transactions.
+	savepoint string
+}
+
+// newTx creates the transaction started with the given BeginTx context.
+func newTx(ctx context.Context, conn *Conn, savepoint string) *Tx {
+	return &Tx{
+		conn:      conn,
+		ctx:       context.WithoutCancel(ctx),
+		savepoint: savepoint,
+	}
 }
 
-// Commit commits the transaction.
+// Commit commits the transaction, for nested transactions it releases the
+// savepoint.
 func (t *Tx) Commit() error {
-	return t.conn.CommitTx(context.Background())
+	if t.savepoint != "" {
+		return t.conn.ReleaseSavepoint(t.ctx, t.savepoint)
+	}
+	return t.conn.CommitTx(t.ctx)
 }
 
-// Rollback rolls back the transaction.
+// Rollback rolls back the transaction, for nested transactions it reverts
+// and releases the savepoint.
 func (t *Tx) Rollback() error {
-	return t.conn.RollbackTx(context.Background())
+	if t.savepoint != "" {
+		return t.conn.rollbackSavepoint(t.ctx, t.savepoint)
+	}
+	return t.conn.RollbackTx(t.ctx)
 }
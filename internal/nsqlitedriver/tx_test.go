@@ -0,0 +1,123 @@
+package nsqlitedriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestBeginQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        TxBeginMode
+		defaultMode TxBeginMode
+		opts        driver.TxOptions
+		want        string
+		expectError bool
+	}{
+		{
+			name: "Default options",
+			want: "BEGIN DEFERRED;",
+		},
+		{
+			name: "Default isolation with context mode",
+			mode: TxBeginImmediate,
+			want: "BEGIN IMMEDIATE;",
+		},
+		{
+			name:        "Default mode",
+			defaultMode: TxBeginImmediate,
+			want:        "BEGIN IMMEDIATE;",
+		},
+		{
+			name:        "Context mode overrides default mode",
+			mode:        TxBeginExclusive,
+			defaultMode: TxBeginImmediate,
+			want:        "BEGIN EXCLUSIVE;",
+		},
+		{
+			name:        "Read-only ignores default mode",
+			defaultMode: TxBeginImmediate,
+			opts:        driver.TxOptions{ReadOnly: true},
+			want:        "BEGIN DEFERRED;",
+		},
+		{
+			name:        "Invalid default mode",
+			defaultMode: TxBeginMode("LAZY"),
+			expectError: true,
+		},
+		{
+			name:        "Invalid context mode",
+			mode:        TxBeginMode("LAZY"),
+			expectError: true,
+		},
+		{
+			name:        "Read-only with immediate context mode",
+			mode:        TxBeginImmediate,
+			opts:        driver.TxOptions{ReadOnly: true},
+			expectError: true,
+		},
+		{
+			name: "Read committed",
+			opts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelReadCommitted)},
+			want: "BEGIN DEFERRED;",
+		},
+		{
+			name: "Snapshot",
+			opts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSnapshot)},
+			want: "BEGIN DEFERRED;",
+		},
+		{
+			name: "Serializable",
+			opts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)},
+			want: "BEGIN IMMEDIATE;",
+		},
+		{
+			name: "Read-only serializable",
+			opts: driver.TxOptions{
+				Isolation: driver.IsolationLevel(sql.LevelSerializable),
+				ReadOnly:  true,
+			},
+			want: "BEGIN DEFERRED;",
+		},
+		{
+			name: "Linearizable",
+			opts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelLinearizable)},
+			want: "BEGIN EXCLUSIVE;",
+		},
+		{
+			name:        "Write committed is not supported",
+			opts:        driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelWriteCommitted)},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.mode != "" {
+				ctx = WithTxBeginMode(ctx, tt.mode)
+			}
+
+			defaultMode := tt.defaultMode
+			if defaultMode == "" {
+				defaultMode = TxBeginDeferred
+			}
+
+			got, err := beginQuery(ctx, tt.opts, defaultMode)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("beginQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
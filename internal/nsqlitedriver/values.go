@@ -0,0 +1,124 @@
+package nsqlitedriver
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// ValueMode selects how the values returned by the server are converted before
+// reaching sql.Rows.Scan.
+type ValueMode int
+
+const (
+	// ValueModeTyped converts values using the declared type of their column:
+	// int64 and float64 for numbers, []byte for BLOB columns and time.Time for
+	// DATE, DATETIME, TIME and TIMESTAMP columns. This is the default.
+	ValueModeTyped ValueMode = iota
+	// ValueModeRaw returns values as decoded from the JSON response, numbers
+	// as json.Number, and text and BLOB values as string.
+	ValueModeRaw
+)
+
+// DefaultTimeFormats are the layouts tried in order to parse the values of
+// time columns. They cover the formats produced by the SQLite date and time
+// functions.
+var DefaultTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"15:04:05.999999999",
+	"15:04",
+}
+
+// julianDayUnixEpoch is the Julian day number of the Unix epoch.
+const julianDayUnixEpoch = 2440587.5
+
+// convertValue converts a value decoded from the server using the declared
+// type of its column.
+func (c *config) convertValue(val any, ct columnType) driver.Value {
+	if c.valueMode == ValueModeRaw {
+		return val
+	}
+
+	switch v := val.(type) {
+	case json.Number:
+		return c.convertNumber(v, ct)
+	case string:
+		return c.convertString(v, ct)
+	}
+
+	return val
+}
+
+// convertNumber converts a numeric value.
+func (c *config) convertNumber(n json.Number, ct columnType) driver.Value {
+	if ct.affinity != affinityReal && !ct.hasScale() {
+		if i, err := n.Int64(); err == nil {
+			switch {
+			case ct.isBool:
+				return i != 0
+			case ct.isTime:
+				return time.Unix(i, 0).In(c.timeLocation())
+			}
+			return i
+		}
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return n.String()
+	}
+	if ct.isTime {
+		return julianDayToTime(f).In(c.timeLocation())
+	}
+	return f
+}
+
+// convertString converts a text value.
+func (c *config) convertString(s string, ct columnType) driver.Value {
+	switch {
+	case ct.affinity == affinityBlob && ct.name != "":
+		if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return b
+		}
+		return []byte(s)
+	case ct.isTime:
+		if t, ok := c.parseTime(s); ok {
+			return t
+		}
+	}
+	return s
+}
+
+// parseTime parses a time value using the configured time formats.
+func (c *config) parseTime(s string) (time.Time, bool) {
+	for _, format := range c.timeFormats {
+		t, err := time.ParseInLocation(format, s, time.UTC)
+		if err == nil {
+			return t.In(c.timeLocation()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// timeLocation returns the location used for the parsed time values.
+func (c *config) timeLocation() *time.Location {
+	if c.timeLocationValue == nil {
+		return time.UTC
+	}
+	return c.timeLocationValue
+}
+
+// julianDayToTime converts a Julian day number to time.Time.
+func julianDayToTime(jd float64) time.Time {
+	seconds := (jd - julianDayUnixEpoch) * 86400
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+}
@@ -0,0 +1,180 @@
+package nsqlitedriver
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConvertValue(t *testing.T) {
+	typed := newConfig()
+	raw := newConfig()
+	raw.valueMode = ValueModeRaw
+
+	tests := []struct {
+		name     string
+		config   *config
+		value    any
+		declared string
+		want     driver.Value
+	}{
+		{
+			name:     "Nil",
+			config:   typed,
+			value:    nil,
+			declared: "INTEGER",
+			want:     nil,
+		},
+		{
+			name:     "Integer",
+			config:   typed,
+			value:    json.Number("42"),
+			declared: "INTEGER",
+			want:     int64(42),
+		},
+		{
+			name:     "Integer without declared type",
+			config:   typed,
+			value:    json.Number("42"),
+			declared: "",
+			want:     int64(42),
+		},
+		{
+			name:     "Real with integer value",
+			config:   typed,
+			value:    json.Number("3"),
+			declared: "REAL",
+			want:     float64(3),
+		},
+		{
+			name:     "Numeric with decimals",
+			config:   typed,
+			value:    json.Number("3.14"),
+			declared: "DECIMAL(10,2)",
+			want:     3.14,
+		},
+		{
+			name:     "Numeric with scale and a whole value",
+			config:   typed,
+			value:    json.Number("3"),
+			declared: "DECIMAL(10,2)",
+			want:     float64(3),
+		},
+		{
+			name:     "Numeric without scale",
+			config:   typed,
+			value:    json.Number("3"),
+			declared: "NUMERIC(8)",
+			want:     int64(3),
+		},
+		{
+			name:     "Boolean",
+			config:   typed,
+			value:    json.Number("1"),
+			declared: "BOOLEAN",
+			want:     true,
+		},
+		{
+			name:     "Text",
+			config:   typed,
+			value:    "hello",
+			declared: "VARCHAR(255)",
+			want:     "hello",
+		},
+		{
+			name:     "Text without declared type",
+			config:   typed,
+			value:    "aGVsbG8=",
+			declared: "",
+			want:     "aGVsbG8=",
+		},
+		{
+			name:     "Blob",
+			config:   typed,
+			value:    "aGVsbG8=",
+			declared: "BLOB",
+			want:     []byte("hello"),
+		},
+		{
+			name:     "Blob with invalid base64",
+			config:   typed,
+			value:    "not base64!",
+			declared: "BLOB",
+			want:     []byte("not base64!"),
+		},
+		{
+			name:     "Datetime",
+			config:   typed,
+			value:    "2024-05-06 07:08:09",
+			declared: "DATETIME",
+			want:     time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		},
+		{
+			name:     "Timestamp with offset",
+			config:   typed,
+			value:    "2024-05-06T07:08:09.5+02:00",
+			declared: "TIMESTAMP",
+			want:     time.Date(2024, 5, 6, 5, 8, 9, 500000000, time.UTC),
+		},
+		{
+			name:     "Date",
+			config:   typed,
+			value:    "2024-05-06",
+			declared: "date",
+			want:     time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Unparsable datetime is kept as text",
+			config:   typed,
+			value:    "yesterday",
+			declared: "DATETIME",
+			want:     "yesterday",
+		},
+		{
+			name:     "Datetime as unix time",
+			config:   typed,
+			value:    json.Number("86400"),
+			declared: "DATETIME",
+			want:     time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Datetime as julian day",
+			config:   typed,
+			value:    json.Number("2440588.5"),
+			declared: "DATETIME",
+			want:     time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Raw integer",
+			config:   raw,
+			value:    json.Number("42"),
+			declared: "INTEGER",
+			want:     json.Number("42"),
+		},
+		{
+			name:     "Raw blob",
+			config:   raw,
+			value:    "aGVsbG8=",
+			declared: "BLOB",
+			want:     "aGVsbG8=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.convertValue(tt.value, parseColumnType(tt.declared))
+			if wantTime, ok := tt.want.(time.Time); ok {
+				gotTime, ok := got.(time.Time)
+				if !ok || !gotTime.Equal(wantTime) {
+					t.Errorf("convertValue() = %#v, want %v", got, wantTime)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
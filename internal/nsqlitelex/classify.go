@@ -0,0 +1,154 @@
+package nsqlitelex
+
+import "slices"
+
+// IsReadOnly reports whether every statement of the given query only
+// reads data and can be safely executed inside a read-only transaction.
+//
+// The classification is conservative, when in doubt the query is considered
+// a write.
+func IsReadOnly(query string) bool {
+	tokens, err := Tokenize(query)
+	if err != nil {
+		return false
+	}
+
+	start := 0
+	for i, tok := range tokens {
+		if tok.Kind != TokenSemicolon {
+			continue
+		}
+		if !isReadOnlyStatement(tokens[start:i]) {
+			return false
+		}
+		start = i + 1
+	}
+
+	return isReadOnlyStatement(tokens[start:])
+}
+
+// readOnlyPragmas are the pragmas that only read data when called with an
+// argument, such as "PRAGMA table_info(users)". Any other pragma called with
+// an argument, such as "PRAGMA user_version(5)", sets its value.
+var readOnlyPragmas = []string{
+	"TABLE_INFO", "TABLE_XINFO", "TABLE_LIST", "INDEX_INFO", "INDEX_XINFO",
+	"INDEX_LIST", "FOREIGN_KEY_LIST", "FOREIGN_KEY_CHECK", "INTEGRITY_CHECK",
+	"QUICK_CHECK",
+}
+
+// isReadOnlyStatement reports whether the statement made of the given tokens
+// only reads data.
+func isReadOnlyStatement(tokens []Token) bool {
+	keywords := []string{}
+	hasAssignment := false
+	// called is the word before the first opening parenthesis, the name of
+	// the pragma in "PRAGMA name(arg)".
+	called := ""
+	hasCall := false
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == TokenWord:
+			keywords = append(keywords, tok.Keyword())
+		case tok.Kind == TokenOperator && tok.Text == "=":
+			hasAssignment = true
+		case tok.Kind == TokenOperator && tok.Text == "(" && !hasCall:
+			hasCall = true
+			if len(keywords) > 0 {
+				called = keywords[len(keywords)-1]
+			}
+		}
+	}
+	if len(keywords) == 0 {
+		return true
+	}
+
+	switch keywords[0] {
+	case "SELECT", "VALUES", "EXPLAIN":
+		return true
+	case "BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return true
+	case "PRAGMA":
+		if hasCall {
+			return !hasAssignment && slices.Contains(readOnlyPragmas, called)
+		}
+		return !hasAssignment
+	case "WITH":
+		for _, keyword := range keywords[1:] {
+			switch keyword {
+			case "INSERT", "UPDATE", "DELETE", "REPLACE":
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// IsTransactionControl reports whether any statement of the given query
+// starts, ends or changes a transaction (BEGIN, COMMIT, END, ROLLBACK,
+// SAVEPOINT or RELEASE).
+//
+// The classification is conservative, a query that can not be tokenized is
+// considered transaction control.
+func IsTransactionControl(query string) bool {
+	tokens, err := Tokenize(query)
+	if err != nil {
+		return true
+	}
+
+	first := true
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == TokenSemicolon:
+			first = true
+		case tok.Kind == TokenWord && first:
+			switch tok.Keyword() {
+			case "BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+				return true
+			}
+			first = false
+		case tok.Kind != TokenSpace && tok.Kind != TokenComment:
+			first = false
+		}
+	}
+
+	return false
+}
+
+// EndsTransaction reports whether any statement of the given query ends the
+// transaction (COMMIT, END or ROLLBACK without TO).
+func EndsTransaction(query string) bool {
+	tokens, err := Tokenize(query)
+	if err != nil {
+		return false
+	}
+
+	keywords := []string{}
+	check := func() bool {
+		if len(keywords) == 0 {
+			return false
+		}
+		switch keywords[0] {
+		case "COMMIT", "END":
+			return true
+		case "ROLLBACK":
+			return !slices.Contains(keywords[1:], "TO")
+		}
+		return false
+	}
+
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case TokenSemicolon:
+			if check() {
+				return true
+			}
+			keywords = keywords[:0]
+		case TokenWord:
+			keywords = append(keywords, tok.Keyword())
+		}
+	}
+
+	return check()
+}
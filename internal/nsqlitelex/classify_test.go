@@ -0,0 +1,96 @@
+package nsqlitelex
+
+import "testing"
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "", want: true},
+		{query: "SELECT * FROM users", want: true},
+		{query: "  select 1", want: true},
+		{query: "-- comment\nSELECT 1", want: true},
+		{query: "/* INSERT */ SELECT 1", want: true},
+		{query: "VALUES (1), (2)", want: true},
+		{query: "EXPLAIN QUERY PLAN SELECT 1", want: true},
+		{query: "PRAGMA table_info(users)", want: true},
+		{query: "PRAGMA main.index_list('users')", want: true},
+		{query: "PRAGMA user_version", want: true},
+		{query: "PRAGMA user_version(5)", want: false},
+		{query: "PRAGMA journal_mode(WAL)", want: false},
+		{query: "PRAGMA main.journal_mode (WAL)", want: false},
+		{query: "PRAGMA user_version = 5", want: false},
+		{query: "PRAGMA foreign_keys = ON", want: false},
+		{query: "WITH t AS (SELECT 1) SELECT * FROM t", want: true},
+		{query: "WITH t AS (SELECT 'delete') SELECT * FROM t", want: true},
+		{query: "WITH t AS (SELECT 1) DELETE FROM users", want: false},
+		{query: "INSERT INTO users (name) VALUES ('a')", want: false},
+		{query: "UPDATE users SET name = 'a'", want: false},
+		{query: "DELETE FROM users", want: false},
+		{query: "CREATE TABLE t (id INTEGER)", want: false},
+		{query: "SAVEPOINT sp1", want: true},
+		{query: "SELECT 1; SELECT 2;", want: true},
+		{query: "SELECT 1; DELETE FROM users", want: false},
+		{query: "SELECT ';DELETE FROM users'", want: true},
+		{query: "SELECT 'unterminated", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := IsReadOnly(tt.query); got != tt.want {
+				t.Errorf("IsReadOnly(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTransactionControl(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT 1", want: false},
+		{query: "SELECT 'BEGIN'", want: false},
+		{query: "BEGIN IMMEDIATE", want: true},
+		{query: "-- start\nbegin", want: true},
+		{query: "SELECT 1; COMMIT", want: true},
+		{query: "SAVEPOINT sp1", want: true},
+		{query: "SELECT end FROM ranges", want: false},
+		{query: "SELECT 'unterminated", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := IsTransactionControl(tt.query); got != tt.want {
+				t.Errorf("IsTransactionControl(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndsTransaction(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT 1", want: false},
+		{query: "COMMIT", want: true},
+		{query: "end transaction", want: true},
+		{query: "ROLLBACK", want: true},
+		{query: "ROLLBACK TRANSACTION", want: true},
+		{query: "ROLLBACK TO sp1", want: false},
+		{query: "ROLLBACK TRANSACTION TO SAVEPOINT sp1", want: false},
+		{query: "SELECT 1; COMMIT", want: true},
+		{query: "BEGIN", want: false},
+		{query: "SELECT end FROM ranges", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := EndsTransaction(tt.query); got != tt.want {
+				t.Errorf("EndsTransaction(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
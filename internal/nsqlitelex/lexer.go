@@ -0,0 +1,227 @@
+// Package nsqlitelex provides a small SQLite aware lexer used to inspect
+// queries before they are sent to the NSQLite server.
+package nsqlitelex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+const (
+	// TokenSpace is a run of whitespace.
+	TokenSpace TokenKind = iota
+	// TokenComment is a "-- ..." or "/* ... */" comment.
+	TokenComment
+	// TokenWord is a bare keyword or identifier.
+	TokenWord
+	// TokenQuotedIdentifier is a "...", `...` or [...] quoted identifier.
+	TokenQuotedIdentifier
+	// TokenString is a '...' string literal.
+	TokenString
+	// TokenBlob is a X'...' blob literal.
+	TokenBlob
+	// TokenNumber is a numeric literal.
+	TokenNumber
+	// TokenParam is a ?, ?NNN, :name, @name or $name placeholder.
+	TokenParam
+	// TokenSemicolon is a statement separator.
+	TokenSemicolon
+	// TokenOperator is any other character or operator.
+	TokenOperator
+)
+
+// Token is a lexical token of a query.
+type Token struct {
+	// Kind is the kind of the token.
+	Kind TokenKind
+	// Text is the text of the token as found in the query.
+	Text string
+	// Pos is the byte offset of the token in the query.
+	Pos int
+}
+
+// Keyword returns the upper cased text of word tokens, or an empty string for
+// any other kind of token.
+func (t Token) Keyword() string {
+	if t.Kind != TokenWord {
+		return ""
+	}
+	return strings.ToUpper(t.Text)
+}
+
+// Tokenize splits the query into tokens. Concatenating the text of every
+// token returns the original query.
+//
+// An error is returned for unterminated string literals and quoted
+// identifiers. Unterminated block comments are accepted as SQLite does.
+func Tokenize(query string) ([]Token, error) {
+	tokens := []Token{}
+
+	for pos := 0; pos < len(query); {
+		kind, end, err := scanToken(query, pos)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, Token{
+			Kind: kind,
+			Text: query[pos:end],
+			Pos:  pos,
+		})
+		pos = end
+	}
+
+	return tokens, nil
+}
+
+// scanToken returns the kind and end offset of the token starting at pos.
+func scanToken(query string, pos int) (TokenKind, int, error) {
+	ch := query[pos]
+	next := byte(0)
+	if pos+1 < len(query) {
+		next = query[pos+1]
+	}
+
+	switch {
+	case isSpace(ch):
+		end := pos + 1
+		for end < len(query) && isSpace(query[end]) {
+			end++
+		}
+		return TokenSpace, end, nil
+
+	case ch == '-' && next == '-':
+		end := strings.IndexByte(query[pos:], '\n')
+		if end < 0 {
+			return TokenComment, len(query), nil
+		}
+		return TokenComment, pos + end + 1, nil
+
+	case ch == '/' && next == '*':
+		end := strings.Index(query[pos+2:], "*/")
+		if end < 0 {
+			return TokenComment, len(query), nil
+		}
+		return TokenComment, pos + 2 + end + 2, nil
+
+	case ch == '\'':
+		end, err := scanQuoted(query, pos, '\'', "string literal")
+		return TokenString, end, err
+
+	case ch == '"' || ch == '`':
+		end, err := scanQuoted(query, pos, ch, "quoted identifier")
+		return TokenQuotedIdentifier, end, err
+
+	case ch == '[':
+		end := strings.IndexByte(query[pos:], ']')
+		if end < 0 {
+			return 0, 0, fmt.Errorf("unterminated quoted identifier at offset %d", pos)
+		}
+		return TokenQuotedIdentifier, pos + end + 1, nil
+
+	case (ch == 'x' || ch == 'X') && next == '\'':
+		end, err := scanQuoted(query, pos+1, '\'', "blob literal")
+		return TokenBlob, end, err
+
+	case isDigit(ch) || (ch == '.' && isDigit(next)):
+		return TokenNumber, scanNumber(query, pos), nil
+
+	case ch == '?':
+		end := pos + 1
+		for end < len(query) && isDigit(query[end]) {
+			end++
+		}
+		return TokenParam, end, nil
+
+	case ch == ':' || ch == '@' || ch == '$':
+		end := pos + 1
+		for end < len(query) && isIdentChar(query[end]) {
+			end++
+		}
+		if end == pos+1 {
+			return TokenOperator, end, nil
+		}
+		return TokenParam, end, nil
+
+	case isIdentStart(ch):
+		end := pos + 1
+		for end < len(query) && isIdentChar(query[end]) {
+			end++
+		}
+		return TokenWord, end, nil
+
+	case ch == ';':
+		return TokenSemicolon, pos + 1, nil
+	}
+
+	return TokenOperator, pos + 1, nil
+}
+
+// scanQuoted returns the end offset of the quoted token starting at pos. A
+// doubled quote character is an escaped quote.
+func scanQuoted(query string, pos int, quote byte, what string) (int, error) {
+	for i := pos + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1, nil
+	}
+	return 0, fmt.Errorf("unterminated %s at offset %d", what, pos)
+}
+
+// scanNumber returns the end offset of the numeric literal starting at pos.
+func scanNumber(query string, pos int) int {
+	end := pos
+	if strings.HasPrefix(strings.ToLower(query[pos:]), "0x") {
+		end += 2
+		for end < len(query) && isHexDigit(query[end]) {
+			end++
+		}
+		return end
+	}
+
+	for end < len(query) && (isDigit(query[end]) || query[end] == '.' || query[end] == '_') {
+		end++
+	}
+	if end < len(query) && (query[end] == 'e' || query[end] == 'E') {
+		exp := end + 1
+		if exp < len(query) && (query[exp] == '+' || query[exp] == '-') {
+			exp++
+		}
+		if exp < len(query) && isDigit(query[exp]) {
+			end = exp
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+		}
+	}
+	return end
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' || ch == '\v'
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// isIdentStart reports whether ch can start a bare identifier, bytes of
+// multi-byte UTF-8 characters are accepted as SQLite does.
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= 0x80
+}
+
+func isIdentChar(ch byte) bool {
+	return isIdentStart(ch) || isDigit(ch) || ch == '$'
+}
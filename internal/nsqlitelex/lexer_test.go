@@ -0,0 +1,270 @@
+package nsqlitelex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		want        []TokenKind
+		expectError bool
+	}{
+		{
+			name:  "Simple select",
+			query: "SELECT id FROM t",
+			want: []TokenKind{
+				TokenWord, TokenSpace, TokenWord, TokenSpace, TokenWord, TokenSpace, TokenWord,
+			},
+		},
+		{
+			name:  "String with escaped quote and placeholder inside",
+			query: "'it''s ?'",
+			want:  []TokenKind{TokenString},
+		},
+		{
+			name:  "Quoted identifiers",
+			query: "\"a\"\"b\"`c`[d e]",
+			want:  []TokenKind{TokenQuotedIdentifier, TokenQuotedIdentifier, TokenQuotedIdentifier},
+		},
+		{
+			name:  "Comments",
+			query: "-- ? \n/* :x */",
+			want:  []TokenKind{TokenComment, TokenComment},
+		},
+		{
+			name:  "Unterminated block comment",
+			query: "/* ?",
+			want:  []TokenKind{TokenComment},
+		},
+		{
+			name:  "Placeholders",
+			query: "? ?12 :a @b $c",
+			want: []TokenKind{
+				TokenParam, TokenSpace, TokenParam, TokenSpace, TokenParam,
+				TokenSpace, TokenParam, TokenSpace, TokenParam,
+			},
+		},
+		{
+			name:  "Numbers and blobs",
+			query: "1.5e10 0x1F .5 X'00'",
+			want: []TokenKind{
+				TokenNumber, TokenSpace, TokenNumber, TokenSpace, TokenNumber,
+				TokenSpace, TokenBlob,
+			},
+		},
+		{
+			name:  "Operators and semicolons",
+			query: "a=1;",
+			want:  []TokenKind{TokenWord, TokenOperator, TokenNumber, TokenSemicolon},
+		},
+		{
+			name:        "Unterminated string",
+			query:       "SELECT 'abc",
+			expectError: true,
+		},
+		{
+			name:        "Unterminated identifier",
+			query:       "SELECT [abc",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.query)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			kinds := make([]TokenKind, len(tokens))
+			texts := make([]string, len(tokens))
+			for i, tok := range tokens {
+				kinds[i] = tok.Kind
+				texts[i] = tok.Text
+			}
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("expected kinds: %v, got: %v (%q)", tt.want, kinds, texts)
+			}
+			if joined := strings.Join(texts, ""); joined != tt.query {
+				t.Errorf("tokens do not rebuild the query: %q", joined)
+			}
+		})
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		want        Params
+		expectError bool
+	}{
+		{
+			name:  "No placeholders",
+			query: "SELECT '?', \":x\" -- ?",
+			want:  Params{},
+		},
+		{
+			name:  "Anonymous placeholders",
+			query: "SELECT ?, ?",
+			want:  Params{Count: 2, Positional: true},
+		},
+		{
+			name:  "Numbered placeholders",
+			query: "SELECT ?3, ?1",
+			want:  Params{Count: 3, Positional: true},
+		},
+		{
+			name:  "Anonymous after numbered",
+			query: "SELECT ?2, ?",
+			want:  Params{Count: 3, Positional: true},
+		},
+		{
+			name:  "Named placeholders",
+			query: "SELECT :a, @b, $c, :a",
+			want:  Params{Count: 3, Names: []string{":a", "@b", "$c"}},
+		},
+		{
+			name:  "Same name with different prefixes",
+			query: "SELECT :a, @a, $a, @a",
+			want:  Params{Count: 3, Names: []string{":a", "@a", "$a"}},
+		},
+		{
+			name:        "Mixed placeholders",
+			query:       "SELECT ?, :a",
+			expectError: true,
+		},
+		{
+			name:        "Index out of range",
+			query:       "SELECT ?0",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := ParseParams(tokens)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "Single statement",
+			query: "SELECT 1",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name:  "Trailing semicolon and comments",
+			query: "SELECT 1; -- done\n",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name:  "Multiple statements",
+			query: "SELECT 1;\nSELECT ';';  SELECT 3",
+			want:  []string{"SELECT 1", "SELECT ';'", "SELECT 3"},
+		},
+		{
+			name:  "Empty statements",
+			query: ";;SELECT 1;;",
+			want:  []string{"SELECT 1"},
+		},
+		{
+			name: "Trigger body",
+			query: "CREATE TEMP TRIGGER tr AFTER INSERT ON t BEGIN " +
+				"UPDATE t SET x = CASE WHEN 1 THEN 2 END; DELETE FROM u; END; SELECT 1",
+			want: []string{
+				"CREATE TEMP TRIGGER tr AFTER INSERT ON t BEGIN " +
+					"UPDATE t SET x = CASE WHEN 1 THEN 2 END; DELETE FROM u; END",
+				"SELECT 1",
+			},
+		},
+		{
+			name:  "Transaction statements",
+			query: "BEGIN; SELECT 1; END;",
+			want:  []string{"BEGIN", "SELECT 1", "END"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := []string{}
+			for _, stmt := range SplitStatements(tokens) {
+				got = append(got, stmt.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStatementIsTransactionControl(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "/* tx */ BEGIN", want: true},
+		{query: "COMMIT", want: true},
+		{query: "rollback to sp", want: true},
+		{query: "SELECT 1", want: false},
+		{
+			query: "CREATE TRIGGER tr AFTER INSERT ON t BEGIN DELETE FROM u; END",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			tokens, err := Tokenize(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			statements := SplitStatements(tokens)
+			if len(statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(statements))
+			}
+			if got := statements[0].IsTransactionControl(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
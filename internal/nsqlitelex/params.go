@@ -0,0 +1,100 @@
+package nsqlitelex
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// maxParamIndex is the default SQLITE_MAX_VARIABLE_NUMBER.
+const maxParamIndex = 32766
+
+// Params describes the placeholders of a query.
+type Params struct {
+	// Count is the number of parameters of the query, following the
+	// sqlite3_bind_parameter_count semantics: the largest parameter index.
+	Count int
+	// Names are the distinct named placeholders with their prefix, in order
+	// of appearance. As in SQLite, :a and @a are distinct placeholders.
+	Names []string
+	// Positional is true when the query uses ? or ?NNN placeholders.
+	Positional bool
+}
+
+// Named reports whether the query uses named placeholders.
+func (p Params) Named() bool {
+	return len(p.Names) > 0
+}
+
+// ArgNames returns the distinct names of the named placeholders without their
+// prefix, the names of the sql.Named arguments that bind them. An argument
+// binds every placeholder with its name, whatever the prefix.
+func (p Params) ArgNames() []string {
+	names := []string{}
+	for _, placeholder := range p.Names {
+		name := placeholder[1:]
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseParams returns the placeholders of the given tokens.
+//
+// Mixing positional (?, ?NNN) and named (:name, @name, $name) placeholders
+// in the same query is rejected to avoid silently binding the wrong values.
+func ParseParams(tokens []Token) (Params, error) {
+	params := Params{}
+	seen := map[string]bool{}
+	firstPositional := -1
+	firstNamed := -1
+
+	for _, tok := range tokens {
+		if tok.Kind != TokenParam {
+			continue
+		}
+
+		if tok.Text[0] != '?' {
+			if firstNamed < 0 {
+				firstNamed = tok.Pos
+			}
+			if !seen[tok.Text] {
+				seen[tok.Text] = true
+				params.Names = append(params.Names, tok.Text)
+				params.Count++
+			}
+			continue
+		}
+
+		if firstPositional < 0 {
+			firstPositional = tok.Pos
+		}
+		params.Positional = true
+
+		if len(tok.Text) == 1 {
+			params.Count++
+			continue
+		}
+
+		idx, err := strconv.Atoi(tok.Text[1:])
+		if err != nil || idx < 1 || idx > maxParamIndex {
+			return Params{}, fmt.Errorf(
+				"invalid placeholder %s at offset %d, index must be between 1 and %d",
+				tok.Text, tok.Pos, maxParamIndex,
+			)
+		}
+		if idx > params.Count {
+			params.Count = idx
+		}
+	}
+
+	if firstPositional >= 0 && firstNamed >= 0 {
+		return Params{}, fmt.Errorf(
+			"query mixes positional (offset %d) and named (offset %d) placeholders",
+			firstPositional, firstNamed,
+		)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,110 @@
+package nsqlitelex
+
+import (
+	"strings"
+)
+
+// Statement is a single statement of a query.
+type Statement struct {
+	// Text is the text of the statement without the trailing semicolon and
+	// surrounding whitespace.
+	Text string
+	// Tokens are the tokens of the statement.
+	Tokens []Token
+}
+
+// SplitStatements splits the tokens of a query into statements at every
+// semicolon, except the ones inside the body of a CREATE TRIGGER statement.
+// Statements without any code, only whitespace and comments, are omitted.
+func SplitStatements(tokens []Token) []Statement {
+	statements := []Statement{}
+
+	start := 0
+	for start < len(tokens) {
+		end := statementEnd(tokens, start)
+		if stmt, ok := newStatement(tokens[start:end]); ok {
+			statements = append(statements, stmt)
+		}
+		start = end + 1
+	}
+
+	return statements
+}
+
+// statementEnd returns the index of the semicolon that ends the statement
+// starting at start, or len(tokens) if it is the last one.
+func statementEnd(tokens []Token, start int) int {
+	keywords := 0
+	isTrigger := false
+	inBody := false
+	caseDepth := 0
+
+	for i := start; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		if tok.Kind == TokenWord {
+			keyword := tok.Keyword()
+			keywords++
+
+			switch {
+			case keywords <= 4 && keyword == "TRIGGER":
+				isTrigger = true
+			case isTrigger && !inBody && keyword == "BEGIN":
+				inBody = true
+			case inBody && keyword == "CASE":
+				caseDepth++
+			case inBody && keyword == "END":
+				if caseDepth > 0 {
+					caseDepth--
+				} else {
+					inBody = false
+				}
+			}
+			continue
+		}
+
+		if tok.Kind == TokenSemicolon && !inBody {
+			return i
+		}
+	}
+
+	return len(tokens)
+}
+
+// IsTransactionControl reports whether the statement starts, ends or changes
+// a transaction (BEGIN, COMMIT, END, ROLLBACK, SAVEPOINT or RELEASE).
+func (s Statement) IsTransactionControl() bool {
+	for _, tok := range s.Tokens {
+		if tok.Kind == TokenSpace || tok.Kind == TokenComment {
+			continue
+		}
+		switch tok.Keyword() {
+		case "BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// newStatement creates a statement from its tokens, it returns false if the
+// tokens contain no code.
+func newStatement(tokens []Token) (Statement, bool) {
+	hasCode := false
+	var b strings.Builder
+	for _, tok := range tokens {
+		if tok.Kind != TokenSpace && tok.Kind != TokenComment {
+			hasCode = true
+		}
+		b.WriteString(tok.Text)
+	}
+
+	if !hasCode {
+		return Statement{}, false
+	}
+
+	return Statement{
+		Text:   strings.TrimSpace(b.String()),
+		Tokens: tokens,
+	}, true
+}
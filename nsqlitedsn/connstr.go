@@ -4,23 +4,88 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ConnStr holds the NSQLite connection string divided into its parts.
 type ConnStr struct {
-	// Protocol can be either "http" or "https" (default is "http").
+	// Protocol can be either "http", "https" or "unix" (default is "http").
 	Protocol string
 	// Host is the IP address or domain name of the server without protocol and
 	// port (default is "localhost").
 	Host string
 	// Port is the port number of the server (default is 9876).
 	Port string
+	// SocketPath is the path of the Unix domain socket of the server, only set
+	// with the "unix" protocol, which has no host and port.
+	SocketPath string
 	// AuthToken is the authentication token sent to the server on every request
 	// (optional).
 	AuthToken string
+	// Replicas are the read replicas of a multi-host connection string, the
+	// first host is always the primary (optional).
+	Replicas []Address
+	// ReadFrom selects the servers that receive the reads sent outside
+	// transactions (default is ReadFromPrimary).
+	ReadFrom ReadFrom
+
+	// Timeout is the timeout of the HTTP requests, 0 uses the client default
+	// (optional).
+	Timeout time.Duration
+	// MaxConns is the maximum number of connections per server, 0 uses the
+	// client default (optional).
+	MaxConns int
+	// MaxIdleConns is the maximum number of idle connections kept per server,
+	// 0 uses the client default (optional).
+	MaxIdleConns int
+	// Retries is the number of times a failed request is retried, 0 disables
+	// retries (optional).
+	Retries int
+
+	// TLSCA is the path of the PEM file with the CA certificates used to
+	// verify the server (optional).
+	TLSCA string
+	// TLSCert is the path of the PEM file with the client certificate, it
+	// requires TLSKey (optional).
+	TLSCert string
+	// TLSKey is the path of the PEM file with the key of the client
+	// certificate, it requires TLSCert (optional).
+	TLSKey string
+	// TLSInsecureSkipVerify disables the verification of the server
+	// certificate, only use it for testing (optional).
+	TLSInsecureSkipVerify bool
+	// TLSServerName is the name used to verify the server certificate,
+	// instead of the host (optional).
+	TLSServerName string
 }
 
+// Address is the host and port of a server.
+type Address struct {
+	// Host is the IP address or domain name of the server.
+	Host string
+	// Port is the port number of the server.
+	Port string
+}
+
+// String returns the address in the "host:port" format.
+func (a Address) String() string {
+	return a.Host + ":" + a.Port
+}
+
+// ReadFrom selects the servers that receive the reads sent outside
+// transactions.
+type ReadFrom string
+
+const (
+	// ReadFromPrimary sends every query to the primary.
+	ReadFromPrimary ReadFrom = "primary"
+	// ReadFromReplicas sends the reads outside transactions to the replicas,
+	// writes and transactions still go to the primary.
+	ReadFromReplicas ReadFrom = "replicas"
+)
+
 // setDefaultsIfEmpty sets the default values for the connection string if they
 // are empty.
 func (c *ConnStr) setDefaultsIfEmpty() {
@@ -28,6 +93,10 @@ func (c *ConnStr) setDefaultsIfEmpty() {
 		c.Protocol = "http"
 	}
 
+	if c.Protocol == "unix" {
+		return
+	}
+
 	if c.Host == "" {
 		c.Host = "localhost"
 	}
@@ -40,24 +109,46 @@ func (c *ConnStr) setDefaultsIfEmpty() {
 // NewConnStrFromStr creates a new ConnStr from a connection string.
 //
 // The connection string must be in the format
-// "protocol://host:port?authToken=token".
+// "protocol://host:port[,host:port...]?authToken=token&readFrom=replicas".
 //
 //   - The protocol must be either "http" or "https".
 //   - The host is the IP address or domain name of the server.
 //   - The port is the port number of the server (default is 9876).
+//   - The first host is the primary, the following ones are read replicas.
 //   - The authToken is the optional authentication token sent to the server on
 //     every request.
+//   - The readFrom is the optional ReadFrom, "primary" or "replicas".
+//
+// A server listening on a Unix domain socket uses the format
+// "unix:///path/to/socket?authToken=token" instead, with a single server.
+//
+// The following optional parameters tune the client:
+//
+//   - timeout: timeout of the HTTP requests, as a Go duration (e.g. "10s").
+//   - maxConns: maximum number of connections per server.
+//   - maxIdleConns: maximum number of idle connections kept per server.
+//   - retries: number of times a failed request is retried.
+//   - tlsCA: path of the PEM file with the CA certificates of the server.
+//   - tlsCert and tlsKey: paths of the PEM files of the client certificate.
+//   - tlsInsecureSkipVerify: "true" disables the server verification.
+//   - tlsServerName: name used to verify the server certificate.
 //
-// If the connection string is invalid, an error is returned.
+// The TLS parameters require the https protocol. If the connection string is
+// invalid or has an unknown parameter, an error is returned.
 func NewConnStrFromText(connStrText string) (*ConnStr, error) {
+	connStrText, replicaHosts := splitHosts(connStrText)
+
 	parsedURL, err := url.Parse(connStrText)
 	if err != nil {
 		return &ConnStr{}, err
 	}
 
 	protocol := parsedURL.Scheme
+	if protocol == "unix" {
+		return newUnixConnStr(parsedURL, replicaHosts)
+	}
 	if protocol != "http" && protocol != "https" {
-		return &ConnStr{}, errors.New("invalid protocol, must be http or https")
+		return &ConnStr{}, errors.New("invalid protocol, must be http, https or unix")
 	}
 
 	host := parsedURL.Hostname()
@@ -70,12 +161,173 @@ func NewConnStrFromText(connStrText string) (*ConnStr, error) {
 		port = "9876"
 	}
 
-	return &ConnStr{
-		Protocol:  protocol,
-		Host:      host,
-		Port:      port,
-		AuthToken: parsedURL.Query().Get("authToken"),
-	}, nil
+	var replicas []Address
+	for _, hostport := range replicaHosts {
+		replica, err := parseAddress(hostport)
+		if err != nil {
+			return &ConnStr{}, err
+		}
+		replicas = append(replicas, replica)
+	}
+
+	connStr := &ConnStr{
+		Protocol: protocol,
+		Host:     host,
+		Port:     port,
+		Replicas: replicas,
+	}
+	if err := connStr.parseParams(parsedURL.Query()); err != nil {
+		return &ConnStr{}, err
+	}
+
+	return connStr, nil
+}
+
+// newUnixConnStr creates a ConnStr from a parsed "unix://" connection string.
+func newUnixConnStr(parsedURL *url.URL, replicaHosts []string) (*ConnStr, error) {
+	if parsedURL.Host != "" || len(replicaHosts) > 0 {
+		return &ConnStr{}, errors.New("unix connection strings must not have a host")
+	}
+	if parsedURL.Path == "" {
+		return &ConnStr{}, errors.New("socket path is required")
+	}
+
+	connStr := &ConnStr{
+		Protocol:   "unix",
+		SocketPath: parsedURL.Path,
+	}
+	if err := connStr.parseParams(parsedURL.Query()); err != nil {
+		return &ConnStr{}, err
+	}
+
+	return connStr, nil
+}
+
+// parseParams parses and validates the query parameters of a connection
+// string.
+func (c *ConnStr) parseParams(params url.Values) error {
+	for name, values := range params {
+		if len(values) > 1 {
+			return fmt.Errorf("parameter %s is set more than once", name)
+		}
+
+		value := values[0]
+		var err error
+		switch name {
+		case "authToken":
+			c.AuthToken = value
+		case "readFrom":
+			c.ReadFrom = ReadFrom(value)
+			if c.ReadFrom != ReadFromPrimary && c.ReadFrom != ReadFromReplicas {
+				err = errors.New("must be primary or replicas")
+			}
+		case "timeout":
+			c.Timeout, err = time.ParseDuration(value)
+			if err == nil && c.Timeout < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "maxConns":
+			c.MaxConns, err = parseNonNegativeInt(value)
+		case "maxIdleConns":
+			c.MaxIdleConns, err = parseNonNegativeInt(value)
+		case "retries":
+			c.Retries, err = parseNonNegativeInt(value)
+		case "tlsCA":
+			c.TLSCA = value
+		case "tlsCert":
+			c.TLSCert = value
+		case "tlsKey":
+			c.TLSKey = value
+		case "tlsInsecureSkipVerify":
+			c.TLSInsecureSkipVerify, err = strconv.ParseBool(value)
+		case "tlsServerName":
+			c.TLSServerName = value
+		default:
+			return fmt.Errorf("unknown parameter %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("tlsCert and tlsKey must be set together")
+	}
+	if c.HasTLSParams() && c.Protocol != "https" {
+		return errors.New("TLS parameters require the https protocol")
+	}
+
+	return nil
+}
+
+// HasTLSParams reports whether any of the TLS parameters is set.
+func (c *ConnStr) HasTLSParams() bool {
+	return c.TLSCA != "" || c.TLSCert != "" || c.TLSKey != "" ||
+		c.TLSInsecureSkipVerify || c.TLSServerName != ""
+}
+
+// parseNonNegativeInt parses a base 10 integer that must not be negative.
+func parseNonNegativeInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return n, nil
+}
+
+// splitHosts splits the hosts of a multi-host connection string. It returns
+// the connection string with only the first host and the remaining hosts.
+func splitHosts(connStrText string) (string, []string) {
+	schemeEnd := strings.Index(connStrText, "://")
+	if schemeEnd < 0 {
+		return connStrText, nil
+	}
+
+	authorityStart := schemeEnd + len("://")
+	authorityEnd := len(connStrText)
+	if idx := strings.IndexAny(connStrText[authorityStart:], "/?#"); idx >= 0 {
+		authorityEnd = authorityStart + idx
+	}
+
+	hosts := strings.Split(connStrText[authorityStart:authorityEnd], ",")
+	if len(hosts) == 1 {
+		return connStrText, nil
+	}
+
+	first := connStrText[:authorityStart] + hosts[0] + connStrText[authorityEnd:]
+	return first, hosts[1:]
+}
+
+// parseAddress parses a "host:port" address, the port defaults to 9876.
+func parseAddress(hostport string) (Address, error) {
+	parsedURL, err := url.Parse("http://" + hostport)
+	if err != nil {
+		return Address{}, err
+	}
+	if parsedURL.Hostname() == "" || parsedURL.Host != hostport {
+		return Address{}, fmt.Errorf("invalid host %q", hostport)
+	}
+
+	port := parsedURL.Port()
+	if port == "" {
+		port = "9876"
+	}
+
+	return Address{Host: parsedURL.Hostname(), Port: port}, nil
+}
+
+// Primary returns the address of the primary server.
+func (c *ConnStr) Primary() Address {
+	c.setDefaultsIfEmpty()
+	return Address{Host: c.Host, Port: c.Port}
+}
+
+// Addresses returns the addresses of every server, the primary first.
+func (c *ConnStr) Addresses() []Address {
+	return append([]Address{c.Primary()}, c.Replicas...)
 }
 
 // String returns the string representation of the connection string without
@@ -83,25 +335,62 @@ func NewConnStrFromText(connStrText string) (*ConnStr, error) {
 func (c *ConnStr) String() string {
 	c.setDefaultsIfEmpty()
 
-	if c.AuthToken == "" {
-		return c.Protocol + "://" + c.Host + ":" + c.Port
+	hosts := []string{}
+	if c.Protocol == "unix" {
+		hosts = append(hosts, c.SocketPath)
+	} else {
+		for _, addr := range c.Addresses() {
+			hosts = append(hosts, addr.String())
+		}
 	}
 
-	return c.Protocol + "://" + c.Host + ":" + c.Port + "?authToken=****"
+	params := []string{}
+	if c.AuthToken != "" {
+		params = append(params, "authToken=****")
+	}
+	if c.ReadFrom != "" {
+		params = append(params, "readFrom="+string(c.ReadFrom))
+	}
+
+	str := c.Protocol + "://" + strings.Join(hosts, ",")
+	if len(params) > 0 {
+		str += "?" + strings.Join(params, "&")
+	}
+	return str
 }
 
-// BaseUrlStr returns the full URL of the connection string without the auth
+// BaseUrlStr returns the full URL of the primary server without the auth
 // token.
 func (c *ConnStr) BaseUrlStr() string {
+	return c.BaseUrlStrFor(c.Primary())
+}
+
+// BaseUrlStrFor returns the full URL of the given server without the auth
+// token.
+//
+// With the "unix" protocol the URL is "http://localhost", the requests are
+// sent over the socket by the HTTP client.
+func (c *ConnStr) BaseUrlStrFor(addr Address) string {
 	c.setDefaultsIfEmpty()
-	return c.Protocol + "://" + c.Host + ":" + c.Port
+	if c.Protocol == "unix" {
+		return "http://localhost"
+	}
+	return c.Protocol + "://" + addr.String()
 }
 
-// CreateUrlStr returns a string URL from the connection string and the
-// provided path.
+// CreateUrlStr returns a string URL of the primary server from the connection
+// string and the provided path.
 //
 // This does not include the auth token in the URL.
 func (c *ConnStr) CreateUrlStr(path string) (string, error) {
+	return c.CreateUrlStrFor(c.Primary(), path)
+}
+
+// CreateUrlStrFor returns a string URL of the given server from the
+// connection string and the provided path.
+//
+// This does not include the auth token in the URL.
+func (c *ConnStr) CreateUrlStrFor(addr Address, path string) (string, error) {
 	c.setDefaultsIfEmpty()
 
 	parts := strings.Split(path, "?")
@@ -111,7 +400,7 @@ func (c *ConnStr) CreateUrlStr(path string) (string, error) {
 		query = parts[1]
 	}
 
-	joined, err := url.JoinPath(c.BaseUrlStr(), path)
+	joined, err := url.JoinPath(c.BaseUrlStrFor(addr), path)
 	if err != nil {
 		return "", fmt.Errorf("failed to join URL path: %w", err)
 	}
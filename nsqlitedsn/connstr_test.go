@@ -1,7 +1,9 @@
 package nsqlitedsn
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestConnStr(t *testing.T) {
@@ -89,6 +91,107 @@ func TestConnStr(t *testing.T) {
 				},
 				expectError: false,
 			},
+			{
+				name:  "Multiple hosts",
+				input: "http://primary:9876,replica1:9000,replica2?authToken=x&readFrom=replicas",
+				expected: ConnStr{
+					Protocol:  "http",
+					Host:      "primary",
+					Port:      "9876",
+					AuthToken: "x",
+					Replicas: []Address{
+						{Host: "replica1", Port: "9000"},
+						{Host: "replica2", Port: "9876"},
+					},
+					ReadFrom: ReadFromReplicas,
+				},
+				expectError: false,
+			},
+			{
+				name:        "Empty replica host",
+				input:       "http://primary:9876,?authToken=x",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:        "Invalid readFrom",
+				input:       "http://primary?readFrom=anywhere",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name: "Client parameters",
+				input: "https://example.com?timeout=10s&maxConns=20&maxIdleConns=5&retries=3" +
+					"&tlsCA=/ca.pem&tlsCert=/cert.pem&tlsKey=/key.pem" +
+					"&tlsInsecureSkipVerify=true&tlsServerName=nsqlite.internal",
+				expected: ConnStr{
+					Protocol:              "https",
+					Host:                  "example.com",
+					Port:                  "9876",
+					Timeout:               10 * time.Second,
+					MaxConns:              20,
+					MaxIdleConns:          5,
+					Retries:               3,
+					TLSCA:                 "/ca.pem",
+					TLSCert:               "/cert.pem",
+					TLSKey:                "/key.pem",
+					TLSInsecureSkipVerify: true,
+					TLSServerName:         "nsqlite.internal",
+				},
+				expectError: false,
+			},
+			{
+				name:        "Unknown parameter",
+				input:       "http://example.com?foo=bar",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:        "Invalid timeout",
+				input:       "http://example.com?timeout=soon",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:        "Negative retries",
+				input:       "http://example.com?retries=-1",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:        "Client certificate without key",
+				input:       "https://example.com?tlsCert=/cert.pem",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:        "TLS parameters over HTTP",
+				input:       "http://example.com?tlsCA=/ca.pem",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:  "Unix socket",
+				input: "unix:///var/run/nsqlite.sock?authToken=x",
+				expected: ConnStr{
+					Protocol:   "unix",
+					SocketPath: "/var/run/nsqlite.sock",
+					AuthToken:  "x",
+				},
+				expectError: false,
+			},
+			{
+				name:        "Unix socket without path",
+				input:       "unix://?authToken=x",
+				expected:    ConnStr{},
+				expectError: true,
+			},
+			{
+				name:        "Unix socket with host",
+				input:       "unix://example.com/var/run/nsqlite.sock",
+				expected:    ConnStr{},
+				expectError: true,
+			},
 			{
 				name:  "Host with subdomain",
 				input: "https://sub.domain.example.com",
@@ -112,7 +215,7 @@ func TestConnStr(t *testing.T) {
 					if err != nil {
 						t.Errorf("did not expect an error but got: %v", err)
 					}
-					if *result != tt.expected {
+					if !reflect.DeepEqual(*result, tt.expected) {
 						t.Errorf("expected: %+v, got: %+v", tt.expected, result)
 					}
 				}
@@ -174,6 +277,27 @@ func TestConnStr(t *testing.T) {
 				},
 				expected: "https://example.com:9876?authToken=****",
 			},
+			{
+				name: "Multiple hosts",
+				connStr: ConnStr{
+					Protocol:  "http",
+					Host:      "primary",
+					Port:      "9876",
+					AuthToken: "secret",
+					Replicas:  []Address{{Host: "replica", Port: "9000"}},
+					ReadFrom:  ReadFromReplicas,
+				},
+				expected: "http://primary:9876,replica:9000?authToken=****&readFrom=replicas",
+			},
+			{
+				name: "Unix socket",
+				connStr: ConnStr{
+					Protocol:   "unix",
+					SocketPath: "/var/run/nsqlite.sock",
+					AuthToken:  "secret",
+				},
+				expected: "unix:///var/run/nsqlite.sock?authToken=****",
+			},
 		}
 
 		for _, tt := range tests {
@@ -317,6 +441,16 @@ func TestConnStr(t *testing.T) {
 				expectedURL: "https://example.com:443/",
 				expectError: false,
 			},
+			{
+				name: "Unix socket",
+				connStr: ConnStr{
+					Protocol:   "unix",
+					SocketPath: "/var/run/nsqlite.sock",
+				},
+				path:        "/query",
+				expectedURL: "http://localhost/query",
+				expectError: false,
+			},
 		}
 
 		for _, tt := range tests {
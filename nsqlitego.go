@@ -3,10 +3,15 @@
 package nsqlitego
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"log/slog"
+	"net/http"
+	"time"
 
 	"github.com/nsqlite/nsqlitego/internal/nsqlitedriver"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
@@ -22,7 +27,215 @@ type Driver = nsqlitedriver.Driver
 // database/sql.OpenDB
 //
 // This is the recommended way to create *sql.DB instances if you
-// want to tune the underlying nsqlitehttp.Client.
-func NewConnector(nsqliteHTTPClient *nsqlitehttp.Client) driver.Connector {
-	return nsqlitedriver.NewConnector(nsqliteHTTPClient)
+// want to tune the underlying nsqlitehttp.Client or the driver behavior.
+func NewConnector(nsqliteHTTPClient *nsqlitehttp.Client, options ...ConnectorOption) driver.Connector {
+	return nsqlitedriver.NewConnector(nsqliteHTTPClient, options...)
 }
+
+// ConnectorOption is a function that configures a connector created with
+// NewConnector.
+type ConnectorOption = nsqlitedriver.ConnectorOption
+
+// ValueMode selects how the values returned by the server are converted before
+// reaching sql.Rows.Scan.
+type ValueMode = nsqlitedriver.ValueMode
+
+const (
+	// ValueModeTyped converts values using the declared type of their column:
+	// int64 and float64 for numbers, []byte for BLOB columns and time.Time for
+	// DATE, DATETIME, TIME and TIMESTAMP columns. This is the default.
+	ValueModeTyped = nsqlitedriver.ValueModeTyped
+	// ValueModeRaw returns values as decoded from the JSON response, numbers
+	// as json.Number, and text and BLOB values as string.
+	ValueModeRaw = nsqlitedriver.ValueModeRaw
+)
+
+// DefaultTimeFormats are the layouts tried in order to parse the values of
+// time columns.
+var DefaultTimeFormats = nsqlitedriver.DefaultTimeFormats
+
+// WithValueMode sets how the values returned by the server are converted
+// before reaching sql.Rows.Scan. Default is ValueModeTyped.
+func WithValueMode(mode ValueMode) ConnectorOption {
+	return nsqlitedriver.WithValueMode(mode)
+}
+
+// WithTimeFormats sets the layouts tried in order to parse the values of
+// DATE, DATETIME, TIME and TIMESTAMP columns. Default is DefaultTimeFormats.
+func WithTimeFormats(formats ...string) ConnectorOption {
+	return nsqlitedriver.WithTimeFormats(formats...)
+}
+
+// WithTimeLocation sets the location of the time values parsed from time
+// columns. Default is UTC.
+func WithTimeLocation(location *time.Location) ConnectorOption {
+	return nsqlitedriver.WithTimeLocation(location)
+}
+
+// TimeFormat selects how time.Time arguments are sent to the server.
+type TimeFormat = nsqlitedriver.TimeFormat
+
+const (
+	// TimeFormatText sends times as UTC text, this is the default.
+	TimeFormatText = nsqlitedriver.TimeFormatText
+	// TimeFormatUnix sends times as the number of seconds since the Unix
+	// epoch.
+	TimeFormatUnix = nsqlitedriver.TimeFormatUnix
+	// TimeFormatJulian sends times as a fractional Julian day number.
+	TimeFormatJulian = nsqlitedriver.TimeFormatJulian
+)
+
+// DefaultParamTimeLayout is the layout used to send time.Time arguments with
+// TimeFormatText.
+const DefaultParamTimeLayout = nsqlitedriver.DefaultParamTimeLayout
+
+// ParamEncoder converts query arguments before they are sent to the server.
+// It returns driver.ErrSkip to leave an argument to the default encoding.
+type ParamEncoder = nsqlitedriver.ParamEncoder
+
+// ParamEncoderFunc is a function that implements ParamEncoder.
+type ParamEncoderFunc = nsqlitedriver.ParamEncoderFunc
+
+// WithParamEncoder sets a custom encoder for the query arguments.
+func WithParamEncoder(encoder ParamEncoder) ConnectorOption {
+	return nsqlitedriver.WithParamEncoder(encoder)
+}
+
+// WithParamTimeFormat sets how time.Time arguments are sent to the server.
+// Default is TimeFormatText.
+func WithParamTimeFormat(format TimeFormat) ConnectorOption {
+	return nsqlitedriver.WithParamTimeFormat(format)
+}
+
+// WithParamTimeLayout sets the layout of time.Time arguments sent with
+// TimeFormatText. Default is DefaultParamTimeLayout.
+func WithParamTimeLayout(layout string) ConnectorOption {
+	return nsqlitedriver.WithParamTimeLayout(layout)
+}
+
+// WithStatementTimeout sets the timeout of the statements executed with a
+// context without deadline. Default is no timeout.
+func WithStatementTimeout(timeout time.Duration) ConnectorOption {
+	return nsqlitedriver.WithStatementTimeout(timeout)
+}
+
+// WithReadOnly rejects every write statement with ErrReadOnly and starts
+// every transaction as read-only.
+func WithReadOnly(readOnly bool) ConnectorOption {
+	return nsqlitedriver.WithReadOnly(readOnly)
+}
+
+// WithDefaultTxBeginMode sets the mode of the transactions started with the
+// default isolation level. Default is TxBeginDeferred.
+func WithDefaultTxBeginMode(mode TxBeginMode) ConnectorOption {
+	return nsqlitedriver.WithDefaultTxBeginMode(mode)
+}
+
+// WithHooks adds hooks called around every Exec, Query, Begin, Commit and
+// Rollback of the connections, see nsqlitehttp.Hook.
+func WithHooks(hooks ...nsqlitehttp.Hook) ConnectorOption {
+	return nsqlitedriver.WithHooks(hooks...)
+}
+
+// WithLogger logs every statement and transaction with log/slog, see
+// nsqlitehttp.LogHook. Default is no logging.
+func WithLogger(logger *slog.Logger) ConnectorOption {
+	return nsqlitedriver.WithLogger(logger)
+}
+
+// WithSlowQueryThreshold logs at warn level the statements whose server
+// execution time exceeds threshold. It requires WithLogger.
+func WithSlowQueryThreshold(threshold time.Duration) ConnectorOption {
+	return nsqlitedriver.WithSlowQueryThreshold(threshold)
+}
+
+// WithLogParams logs the values of the query parameters instead of
+// redacting them. It requires WithLogger.
+func WithLogParams(logParams bool) ConnectorOption {
+	return nsqlitedriver.WithLogParams(logParams)
+}
+
+// WithTracer starts a span for every statement and transaction, see
+// nsqlitehttp.Tracer.
+func WithTracer(tracer nsqlitehttp.Tracer) ConnectorOption {
+	return nsqlitedriver.WithTracer(tracer)
+}
+
+// ErrReadOnly is returned when a write statement is executed in read-only
+// mode, for example inside a transaction started with
+// sql.TxOptions{ReadOnly: true}.
+var ErrReadOnly = nsqlitedriver.ErrReadOnly
+
+// ErrTxInterrupted is returned when a request of an open transaction fails
+// because of a transport failure, the state of the transaction on the server
+// is unknown and the connection is discarded.
+var ErrTxInterrupted = nsqlitedriver.ErrTxInterrupted
+
+// ErrBlobParam is returned for []byte query arguments, BLOB parameters are not
+// supported by the NSQLite HTTP API. Send the value hex encoded and decode it
+// with unhex(?) instead.
+var ErrBlobParam = nsqlitedriver.ErrBlobParam
+
+// TxBeginMode is the SQLite transaction behavior used to start a transaction
+// (DEFERRED, IMMEDIATE or EXCLUSIVE).
+type TxBeginMode = nsqlitedriver.TxBeginMode
+
+const (
+	TxBeginDeferred  = nsqlitedriver.TxBeginDeferred
+	TxBeginImmediate = nsqlitedriver.TxBeginImmediate
+	TxBeginExclusive = nsqlitedriver.TxBeginExclusive
+)
+
+// WithTxBeginMode returns a copy of ctx that makes sql.DB.BeginTx start the
+// transaction using the given mode when no isolation level is requested.
+func WithTxBeginMode(ctx context.Context, mode TxBeginMode) context.Context {
+	return nsqlitedriver.WithTxBeginMode(ctx, mode)
+}
+
+// WithReadFrom returns a copy of ctx that overrides the readFrom parameter of
+// the DSN for the statements executed with it, see nsqlitehttp.WithReadFrom.
+func WithReadFrom(ctx context.Context, readFrom nsqlitedsn.ReadFrom) context.Context {
+	return nsqlitehttp.WithReadFrom(ctx, readFrom)
+}
+
+// WithRequestAuth returns a copy of ctx that sends the given auth token with
+// the statements executed with it, see nsqlitehttp.WithRequestAuth.
+func WithRequestAuth(ctx context.Context, token string) context.Context {
+	return nsqlitehttp.WithRequestAuth(ctx, token)
+}
+
+// WithRequestHeaders returns a copy of ctx that adds the given headers to the
+// statements executed with it, see nsqlitehttp.WithRequestHeaders.
+func WithRequestHeaders(ctx context.Context, headers http.Header) context.Context {
+	return nsqlitehttp.WithRequestHeaders(ctx, headers)
+}
+
+// ErrSavepointNotActive is returned when releasing or rolling back to a
+// savepoint that does not exist in the current transaction.
+var ErrSavepointNotActive = nsqlitedriver.ErrSavepointNotActive
+
+// SavepointConn is implemented by the driver connections and gives access to
+// SQLite savepoints from database/sql through sql.Conn.Raw:
+//
+//	err := conn.Raw(func(driverConn any) error {
+//		sp := driverConn.(nsqlitego.SavepointConn)
+//		return sp.Savepoint(ctx, "my_savepoint")
+//	})
+//
+// Calling BeginTx on a connection with an active transaction also creates a
+// savepoint, so nested transactions can be committed or rolled back
+// independently.
+type SavepointConn interface {
+	// Savepoint creates a savepoint in the current transaction.
+	Savepoint(ctx context.Context, name string) error
+	// ReleaseSavepoint releases the savepoint and every savepoint created
+	// after it.
+	ReleaseSavepoint(ctx context.Context, name string) error
+	// RollbackToSavepoint reverts the current transaction to the savepoint,
+	// which stays active.
+	RollbackToSavepoint(ctx context.Context, name string) error
+	// SavepointDepth returns the number of active savepoints.
+	SavepointDepth() int
+}
+
+var _ SavepointConn = (*nsqlitedriver.Conn)(nil)
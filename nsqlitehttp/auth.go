@@ -0,0 +1,220 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// TokenSource provides the auth token sent to the server in the Authorization
+// header. Implementations must be safe for concurrent use.
+type TokenSource interface {
+	// Token returns the current token, an empty token sends no Authorization
+	// header.
+	Token(ctx context.Context) (string, error)
+	// Invalidate discards the cached token after the server rejected it, so
+	// the next call to Token returns a fresh one.
+	Invalidate()
+}
+
+// WithTokenSource sets the source of the auth token sent on every request,
+// instead of the authToken parameter of the connection string.
+//
+// When the server answers 401 Unauthorized, the token is invalidated and the
+// request is sent once more if the token source returns a fresh token.
+func WithTokenSource(source TokenSource) ClientOption {
+	return func(c *Client) error {
+		if source == nil {
+			return errors.New("token source must not be nil")
+		}
+		c.tokenSource = source
+		return nil
+	}
+}
+
+// staticTokenSource is a TokenSource that always returns the same token.
+type staticTokenSource struct {
+	token string
+}
+
+// StaticTokenSource returns a TokenSource that always returns the given token.
+func StaticTokenSource(token string) TokenSource {
+	return &staticTokenSource{token: token}
+}
+
+// Token returns the static token.
+func (s *staticTokenSource) Token(context.Context) (string, error) {
+	return s.token, nil
+}
+
+// Invalidate does nothing, the token can not change.
+func (s *staticTokenSource) Invalidate() {}
+
+// fileTokenSource is a TokenSource that reads the token from a file.
+type fileTokenSource struct {
+	cache *fileCache[string]
+}
+
+// FileTokenSource returns a TokenSource that reads the token from the given
+// file, ignoring leading and trailing whitespace. The file is read again when
+// it changes or the token is invalidated, so a secrets manager can rotate it.
+func FileTokenSource(path string) TokenSource {
+	return &fileTokenSource{cache: &fileCache[string]{
+		paths: []string{path},
+		load: func() (string, error) {
+			token, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read auth token: %w", err)
+			}
+			return strings.TrimSpace(string(token)), nil
+		},
+	}}
+}
+
+// Token returns the token of the file.
+func (s *fileTokenSource) Token(context.Context) (string, error) {
+	return s.cache.get()
+}
+
+// Invalidate makes the next call to Token read the file again.
+func (s *fileTokenSource) Invalidate() {
+	s.cache.invalidate()
+}
+
+// envTokenSource is a TokenSource that reads the token from an environment
+// variable.
+type envTokenSource struct {
+	name string
+}
+
+// EnvTokenSource returns a TokenSource that reads the token from the given
+// environment variable on every request.
+func EnvTokenSource(name string) TokenSource {
+	return &envTokenSource{name: name}
+}
+
+// Token returns the value of the environment variable.
+func (s *envTokenSource) Token(context.Context) (string, error) {
+	token, ok := os.LookupEnv(s.name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", s.name)
+	}
+	return token, nil
+}
+
+// Invalidate does nothing, the variable is read on every request.
+func (s *envTokenSource) Invalidate() {}
+
+// requestAuthKey is the context key of the per-request auth token.
+type requestAuthKey struct{}
+
+// WithRequestAuth returns a copy of ctx that sends the given auth token with
+// the requests made with it, instead of the token of the client. This lets a
+// single client serve several tenants with their own tokens.
+//
+// An empty token sends no Authorization header. Requests rejected with 401
+// Unauthorized are not sent again, as the token source is not used.
+func WithRequestAuth(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, requestAuthKey{}, token)
+}
+
+// requestAuthFromContext returns the auth token stored in ctx, if any.
+func requestAuthFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(requestAuthKey{}).(string)
+	return token, ok
+}
+
+// requestHeadersKey is the context key of the per-request headers.
+type requestHeadersKey struct{}
+
+// WithRequestHeaders returns a copy of ctx that adds the given headers to the
+// requests made with it, for example to identify the tenant of the request.
+// The headers already stored in ctx are kept, except the ones with the same
+// name.
+//
+// The Content-Type, Authorization and traceparent headers are set by the
+// client and can not be overridden, use WithRequestAuth for the token.
+func WithRequestHeaders(ctx context.Context, headers http.Header) context.Context {
+	merged := http.Header{}
+	if existing, ok := requestHeadersFromContext(ctx); ok {
+		merged = existing.Clone()
+	}
+	for name, values := range headers {
+		merged[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+	}
+	return context.WithValue(ctx, requestHeadersKey{}, merged)
+}
+
+// requestHeadersFromContext returns the headers stored in ctx, if any.
+func requestHeadersFromContext(ctx context.Context) (http.Header, bool) {
+	headers, ok := ctx.Value(requestHeadersKey{}).(http.Header)
+	return headers, ok
+}
+
+// setAuthorization sets the Authorization header of the request to the token
+// of its context or, if there is none, of the token source of the client.
+func (c *Client) setAuthorization(request *http.Request) error {
+	token, ok := requestAuthFromContext(request.Context())
+	if !ok {
+		if c.tokenSource == nil {
+			return nil
+		}
+
+		var err error
+		token, err = c.tokenSource.Token(request.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get auth token: %w", err)
+		}
+	}
+
+	if token == "" {
+		request.Header.Del("Authorization")
+	} else {
+		request.Header.Set("Authorization", token)
+	}
+	return nil
+}
+
+// do sends the request. If the server rejects the token with 401
+// Unauthorized, the token is invalidated and the request is sent once more
+// if the token source returns a different token.
+func (c *Client) do(request *http.Request) (*http.Response, error) {
+	response, err := c.roundTrip(request)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || c.tokenSource == nil {
+		return response, err
+	}
+	if _, ok := requestAuthFromContext(request.Context()); ok {
+		return response, nil
+	}
+	if request.Body != nil && request.GetBody == nil {
+		return response, nil
+	}
+
+	c.tokenSource.Invalidate()
+	retry := request.Clone(request.Context())
+	if err := c.setAuthorization(retry); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	if retry.Header.Get("Authorization") == request.Header.Get("Authorization") {
+		return response, nil
+	}
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, 1024))
+	response.Body.Close()
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request body: %w", err)
+		}
+		retry.Body = body
+	}
+
+	return c.roundTrip(retry)
+}
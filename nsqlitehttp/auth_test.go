@@ -0,0 +1,187 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenSourceRefreshOnUnauthorized(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "new" || len(body) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],"rows":[[1]]}]}`))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("old\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+
+	client, err := NewClient(server.URL, WithTokenSource(FileTokenSource(tokenFile)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	queries := []Query{{Query: "SELECT 1"}}
+
+	if _, err := client.SendQueries(context.Background(), queries); !IsAuth(err) {
+		t.Fatalf("expected unauthorized error, got: %v", err)
+	}
+	if got := attempts.Swap(0); got != 1 {
+		t.Errorf("expected 1 attempt with an unchanged token, got %d", got)
+	}
+
+	// Rotate the token keeping the same modification time, so only the
+	// invalidation after the 401 reads it again.
+	info, err := os.Stat(tokenFile)
+	if err != nil {
+		t.Fatalf("failed to stat token: %v", err)
+	}
+	if err := os.WriteFile(tokenFile, []byte("new\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	if err := os.Chtimes(tokenFile, time.Time{}, info.ModTime()); err != nil {
+		t.Fatalf("failed to change times of token: %v", err)
+	}
+
+	if _, err := client.SendQueries(context.Background(), queries); err != nil {
+		t.Fatalf("expected the refreshed token to be accepted, got: %v", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestTokenSources(t *testing.T) {
+	t.Setenv("NSQLITE_TEST_TOKEN", "from-env")
+
+	tests := []struct {
+		name        string
+		source      TokenSource
+		want        string
+		expectError bool
+	}{
+		{
+			name:   "Static",
+			source: StaticTokenSource("static"),
+			want:   "static",
+		},
+		{
+			name:   "Env",
+			source: EnvTokenSource("NSQLITE_TEST_TOKEN"),
+			want:   "from-env",
+		},
+		{
+			name:        "Unset env",
+			source:      EnvTokenSource("NSQLITE_TEST_TOKEN_UNSET"),
+			expectError: true,
+		},
+		{
+			name:        "Missing file",
+			source:      FileTokenSource("/does/not/exist"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Token(context.Background())
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected token %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestAuth(t *testing.T) {
+	type seen struct{ auth, tenant, contentType string }
+	requests := make(chan seen, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- seen{
+			auth:        r.Header.Get("Authorization"),
+			tenant:      r.Header.Get("X-Tenant"),
+			contentType: r.Header.Get("Content-Type"),
+		}
+		if r.Header.Get("Authorization") == "rejected" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "?authToken=default")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	tenantCtx := WithRequestHeaders(context.Background(), http.Header{
+		"x-tenant":     {"a"},
+		"Content-Type": {"text/plain"},
+	})
+	tenantCtx = WithRequestAuth(tenantCtx, "tenant-a")
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		want        seen
+		expectError bool
+	}{
+		{
+			name: "Client token",
+			ctx:  context.Background(),
+			want: seen{auth: "default", contentType: "application/json"},
+		},
+		{
+			name: "Request token and headers",
+			ctx:  tenantCtx,
+			want: seen{auth: "tenant-a", tenant: "a", contentType: "application/json"},
+		},
+		{
+			name: "Headers are merged",
+			ctx:  WithRequestHeaders(tenantCtx, http.Header{"X-Tenant": {"b"}}),
+			want: seen{auth: "tenant-a", tenant: "b", contentType: "application/json"},
+		},
+		{
+			name: "Empty request token",
+			ctx:  WithRequestAuth(context.Background(), ""),
+			want: seen{contentType: "application/json"},
+		},
+		{
+			name:        "Rejected request token is not refreshed",
+			ctx:         WithRequestAuth(context.Background(), "rejected"),
+			want:        seen{auth: "rejected", contentType: "application/json"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.SendPing(tt.ctx)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if got := <-requests; got != tt.want {
+				t.Errorf("expected request %+v, got %+v", tt.want, got)
+			}
+			if len(requests) > 0 {
+				t.Errorf("expected a single request")
+			}
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package nsqlitehttp
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/nsqlite/nsqlitego/nsqlitedsn"
@@ -17,6 +22,38 @@ import (
 type Client struct {
 	connStr *nsqlitedsn.ConnStr
 	httpc   *http.Client
+
+	// nextReplica is the round robin counter of the replicas receiving
+	// reads.
+	nextReplica atomic.Uint64
+
+	// failover is nil when the connection string has a single host.
+	failover      *failover
+	probeInterval time.Duration
+
+	// tlsConfig, rootCAs and clientCert are nil when they are not set, they
+	// are applied to the transport once the options are applied.
+	tlsConfig  *tls.Config
+	rootCAs    *certPoolFile
+	clientCert *keyPairFile
+
+	// compression is nil when the requests are not compressed.
+	compression *Compression
+	// tokenSource is nil when no auth token is sent.
+	tokenSource TokenSource
+
+	// retryPolicy is nil when failed requests are not retried.
+	retryPolicy *RetryPolicy
+	// hooks are called around every batch sent with SendQueries or
+	// StreamQueries.
+	hooks HookChain
+
+	// tracer is nil when tracing is disabled.
+	tracer Tracer
+	// logger is nil when logging is disabled.
+	logger             *slog.Logger
+	slowQueryThreshold time.Duration
+	logParams          bool
 }
 
 // ClientOption is a function that configures a Client.
@@ -32,6 +69,7 @@ func WithHTTPTimeout(timeout time.Duration) ClientOption {
 
 // WithHTTPTransport sets the transport for the default NSQLite HTTP client. The default is
 // http.DefaultTransport with MaxIdleConns, MaxConnsPerHost, and MaxIdleConnsPerHost set to 100.
+// The TLS options, such as WithTLSConfig, configure the given transport.
 func WithHTTPTransport(transport *http.Transport) ClientOption {
 	return func(c *Client) error {
 		c.httpc.Transport = transport
@@ -64,8 +102,15 @@ func NewClient(connectionString string, options ...ClientOption) (*Client, error
 	}
 
 	client := &Client{
-		connStr: connStr,
-		httpc:   httpClient,
+		connStr:       connStr,
+		httpc:         httpClient,
+		probeInterval: DefaultProbeInterval,
+	}
+
+	// The parameters of the connection string are applied before the
+	// options, so the options take precedence.
+	if err := client.applyConnStr(transport); err != nil {
+		return nil, err
 	}
 
 	for idx, opt := range options {
@@ -74,12 +119,89 @@ func NewClient(connectionString string, options ...ClientOption) (*Client, error
 		}
 	}
 
+	if err := client.applyTLS(); err != nil {
+		return nil, err
+	}
+
+	builtin := BuiltinHooks(client.tracer, &LogHook{
+		Logger:             client.logger,
+		SlowQueryThreshold: client.slowQueryThreshold,
+		LogParams:          client.logParams,
+	})
+	client.hooks = append(builtin, client.hooks...)
+
+	if len(connStr.Replicas) > 0 {
+		client.failover = newFailover(connStr.Addresses())
+		if client.probeInterval > 0 {
+			client.failover.stopped.Add(1)
+			go client.probe(client.probeInterval)
+		}
+	}
+
 	return client, nil
 }
 
-// newRequest creates a new HTTP request with the NSQLite URL and authentication
+// applyConnStr applies the client parameters of the connection string to the
+// client and its default transport.
+func (c *Client) applyConnStr(transport *http.Transport) error {
+	if c.connStr.Timeout > 0 {
+		c.httpc.Timeout = c.connStr.Timeout
+	}
+	if c.connStr.MaxConns > 0 {
+		transport.MaxConnsPerHost = c.connStr.MaxConns
+	}
+	if c.connStr.MaxIdleConns > 0 {
+		transport.MaxIdleConns = c.connStr.MaxIdleConns
+		transport.MaxIdleConnsPerHost = c.connStr.MaxIdleConns
+	}
+	if c.connStr.AuthToken != "" {
+		c.tokenSource = StaticTokenSource(c.connStr.AuthToken)
+	}
+	if c.connStr.Retries > 0 {
+		policy := DefaultRetryPolicy
+		policy.MaxAttempts = c.connStr.Retries + 1
+		c.retryPolicy = &policy
+	}
+
+	if c.connStr.SocketPath != "" {
+		// Every request goes to the socket whatever the host of its URL, and
+		// never through a proxy.
+		socketPath := c.connStr.SocketPath
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	}
+
+	if err := c.applyConnStrTLS(); err != nil {
+		return fmt.Errorf("invalid connection string: %w", err)
+	}
+
+	return nil
+}
+
+// Close releases the resources held by the client, such as idle
+// connections and the background health checks. The client must not be used
+// after calling Close.
+func (c *Client) Close() error {
+	if c.failover != nil {
+		c.failover.close()
+	}
+	c.httpc.CloseIdleConnections()
+	return nil
+}
+
+// newRequest creates a new HTTP request with the NSQLite URL of the active
+// server and authentication
 func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
-	url, err := c.connStr.CreateUrlStr(path)
+	return c.newRequestTo(ctx, c.activeAddress(), method, path, body)
+}
+
+// newRequestTo creates a new HTTP request with the NSQLite URL of the given
+// server and authentication
+func (c *Client) newRequestTo(ctx context.Context, addr nsqlitedsn.Address, method string, path string, body io.Reader) (*http.Request, error) {
+	url, err := c.connStr.CreateUrlStrFor(addr, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create URL: %w", err)
 	}
@@ -88,31 +210,60 @@ func (c *Client) newRequest(ctx context.Context, method string, path string, bod
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if headers, ok := requestHeadersFromContext(ctx); ok {
+		for name, values := range headers {
+			request.Header[name] = slices.Clone(values)
+		}
+	}
 	request.Header.Set("Content-Type", "application/json")
+	if c.compression != nil {
+		request.Header.Set("Accept-Encoding", "gzip")
+	}
 
-	if c.connStr.AuthToken != "" {
-		request.Header.Set("Authorization", c.connStr.AuthToken)
+	if err := c.setAuthorization(request); err != nil {
+		return nil, err
+	}
+
+	if span, ok := spanFromContext(ctx); ok {
+		if traceParent := span.TraceParent(); traceParent != "" {
+			request.Header.Set("traceparent", traceParent)
+		}
 	}
 
 	return request, nil
 }
 
+// checkResponse returns an *Error if the response status is not 200 OK.
+func checkResponse(response *http.Response) error {
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return newStatusError(response, string(body))
+}
+
 // SendPing sends a request to the server to check if it is alive. Returns an error
 // if the server is not alive.
 func (c *Client) SendPing(ctx context.Context) error {
-	request, err := c.newRequest(ctx, http.MethodGet, "/health", nil)
+	return c.sendPingTo(ctx, c.activeAddress())
+}
+
+// sendPingTo sends a health check request to the given server.
+func (c *Client) sendPingTo(ctx context.Context, addr nsqlitedsn.Address) error {
+	request, err := c.newRequestTo(ctx, addr, http.MethodGet, "/health", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	response, err := c.httpc.Do(request)
+	response, err := c.do(request)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return err
 	}
 	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("unwanted response status %s", response.Status)
+	if err := checkResponse(response); err != nil {
+		return err
 	}
 
 	body, err := io.ReadAll(response.Body)
@@ -146,18 +297,14 @@ func (c *Client) GetVersion(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	response, err := c.httpc.Do(request)
+	response, err := c.do(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
 	defer response.Body.Close()
 
-	if response.StatusCode == http.StatusUnauthorized {
-		return "", fmt.Errorf("authentication failed, please check your credentials")
-	}
-
-	if response.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unwanted response status: %s", response.Status)
+	if err := checkResponse(response); err != nil {
+		return "", err
 	}
 
 	body, err := io.ReadAll(response.Body)
@@ -229,6 +376,22 @@ type QueryResponse struct {
 	//	- Included in "Type=read" responses.
 	//	- Included in "Type=write" responses if there are rows in the response.
 	Rows [][]any `json:"rows,omitempty"`
+
+	// index is the position of the response in the batch.
+	index int
+}
+
+// Err returns an *Error if the query failed, otherwise nil.
+func (r *QueryResponse) Err() error {
+	if r.Type != QueryResponseTypeError && r.Error == "" {
+		return nil
+	}
+
+	message := r.Error
+	if message == "" {
+		message = "unknown error"
+	}
+	return newQueryError(r.index, message)
 }
 
 // QueryParam represents a named (?NNN, :VVV, @VVV, $VVV) or nameless (?) parameter in a SQL query.
@@ -252,47 +415,106 @@ type Query struct {
 	TxID string `json:"txId,omitempty"`
 }
 
+// cloneQueries returns a copy of the queries and of their params.
+func cloneQueries(queries []Query) []Query {
+	cloned := slices.Clone(queries)
+	for i := range cloned {
+		cloned[i].Params = slices.Clone(cloned[i].Params)
+	}
+	return cloned
+}
+
 // SendQueries sends one or more queries to the remote server and returns the responses in same order.
+//
+// Every row of the responses is loaded in memory, use StreamQueries to read
+// large result sets incrementally.
+//
+// The batch is retried according to the retry policy of the client, see
+// WithRetryPolicy. A query still failing after the last attempt is reported
+// through its response, see QueryResponse.Err.
+//
+// The hooks of the client are called around the batch, see WithHooks.
 func (c *Client) SendQueries(ctx context.Context, queries []Query) ([]QueryResponse, error) {
-	requestBody, err := json.Marshal(queries)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	if len(c.hooks) == 0 {
+		return c.sendQueriesWithRetry(ctx, queries)
 	}
 
-	request, err := c.newRequest(ctx, http.MethodPost, "/query", bytes.NewReader(requestBody))
+	event := newBatchEvent(HookOpBatch, queries)
+	ctx, err := c.hooks.Before(ctx, event)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		c.finishEvent(ctx, event, time.Now(), nil, err)
+		return nil, err
 	}
 
-	response, err := c.httpc.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer response.Body.Close()
+	start := time.Now()
+	results, err := c.sendQueriesWithRetry(ctx, event.Queries)
+	c.finishEvent(ctx, event, start, results, err)
+
+	return results, err
+}
 
-	if response.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("authentication failed, please check your credentials")
+// sendQueriesWithRetry sends the queries according to the retry policy of
+// the client.
+func (c *Client) sendQueriesWithRetry(ctx context.Context, queries []Query) ([]QueryResponse, error) {
+	var results []QueryResponse
+	err := c.retry(ctx, queries, func() error {
+		var err error
+		results, err = c.sendQueries(ctx, queries)
+		if err != nil {
+			return err
+		}
+
+		for _, resp := range results {
+			if err := resp.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if results != nil {
+		return results, nil
 	}
 
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unwanted response status: %s", response.Status)
+	return nil, err
+}
+
+// sendQueries sends the queries once and collects every response.
+func (c *Client) sendQueries(ctx context.Context, queries []Query) ([]QueryResponse, error) {
+	stream, err := c.streamQueries(ctx, queries)
+	if err != nil {
+		return nil, err
 	}
+	defer stream.Close()
 
-	result := struct {
-		Results []QueryResponse `json:"results"`
-	}{}
+	results := []QueryResponse{}
+	for {
+		resp, err := stream.NextResult()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	decoder := json.NewDecoder(response.Body)
-	decoder.UseNumber()
-	if err := decoder.Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		for {
+			row, err := stream.NextRow()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			resp.Rows = append(resp.Rows, row)
+		}
+
+		results = append(results, *resp)
 	}
 
-	if len(result.Results) == 0 {
+	if len(results) == 0 {
 		return nil, fmt.Errorf("empty response")
 	}
 
-	return result.Results, nil
+	return results, nil
 }
 
 // SendQuery sends a single query to the remote server and returns the response.
@@ -366,18 +588,14 @@ func (c *Client) GetStats(ctx context.Context) (Stats, error) {
 		return Stats{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	response, err := c.httpc.Do(request)
+	response, err := c.do(request)
 	if err != nil {
-		return Stats{}, fmt.Errorf("failed to send request: %w", err)
+		return Stats{}, err
 	}
 	defer response.Body.Close()
 
-	if response.StatusCode == http.StatusUnauthorized {
-		return Stats{}, fmt.Errorf("authentication failed, please check your credentials")
-	}
-
-	if response.StatusCode != http.StatusOK {
-		return Stats{}, fmt.Errorf("unwanted response status: %s", response.Status)
+	if err := checkResponse(response); err != nil {
+		return Stats{}, err
 	}
 
 	result := Stats{}
@@ -0,0 +1,103 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewClientConnStrParams(t *testing.T) {
+	client, err := NewClient("http://example.com?timeout=5s&maxConns=10&maxIdleConns=4&retries=2")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	transport := client.httpc.Transport.(*http.Transport)
+	if client.httpc.Timeout != 5*time.Second {
+		t.Errorf("expected timeout 5s, got %v", client.httpc.Timeout)
+	}
+	if transport.MaxConnsPerHost != 10 {
+		t.Errorf("expected MaxConnsPerHost 10, got %d", transport.MaxConnsPerHost)
+	}
+	if transport.MaxIdleConns != 4 || transport.MaxIdleConnsPerHost != 4 {
+		t.Errorf("expected 4 idle connections, got %d/%d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if client.retryPolicy == nil || client.retryPolicy.MaxAttempts != 3 {
+		t.Errorf("expected 3 attempts, got %+v", client.retryPolicy)
+	}
+
+	client, err = NewClient("http://example.com?timeout=5s", WithHTTPTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if client.httpc.Timeout != time.Second {
+		t.Errorf("expected the option to take precedence, got %v", client.httpc.Timeout)
+	}
+
+	if _, err := NewClient("https://example.com?tlsCA=/does/not/exist.pem"); err == nil {
+		t.Errorf("expected an error for a missing CA file")
+	}
+}
+
+func TestNewClientTLSCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	client, err := NewClient(server.URL + "?tlsCA=" + caFile + "&tlsServerName=example.com")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := client.SendPing(context.Background()); err != nil {
+		t.Errorf("expected the server to be trusted, got: %v", err)
+	}
+}
+
+func TestNewClientUnixSocket(t *testing.T) {
+	// t.TempDir may exceed the maximum length of a socket path.
+	dir, err := os.MkdirTemp("", "nsqlite")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "nsqlite.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || r.Header.Get("Authorization") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient("unix://" + socketPath + "?authToken=secret")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.SendPing(context.Background()); err != nil {
+		t.Errorf("expected the ping to go through the socket, got: %v", err)
+	}
+}
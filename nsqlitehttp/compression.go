@@ -0,0 +1,101 @@
+package nsqlitehttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Compression configures the gzip compression of the requests and responses
+// of a Client.
+type Compression struct {
+	// Level is the gzip compression level of the request bodies, such as
+	// gzip.BestSpeed or gzip.DefaultCompression.
+	Level int
+	// Threshold is the minimum size in bytes of a request body to compress
+	// it, smaller bodies are sent as is. 0 compresses every body.
+	Threshold int
+}
+
+// DefaultCompression is a sensible Compression for most workloads.
+var DefaultCompression = Compression{
+	Level:     gzip.DefaultCompression,
+	Threshold: 1024,
+}
+
+// WithCompression enables the gzip compression of the query request bodies
+// larger than the threshold of the given configuration, and asks the server
+// for compressed responses, which are decoded while they are read. By default
+// requests are sent uncompressed.
+func WithCompression(compression Compression) ClientOption {
+	return func(c *Client) error {
+		if compression.Level < gzip.HuffmanOnly || compression.Level > gzip.BestCompression {
+			return fmt.Errorf("invalid compression level %d", compression.Level)
+		}
+		if compression.Threshold < 0 {
+			return errors.New("compression threshold must not be negative")
+		}
+
+		c.compression = &compression
+		return nil
+	}
+}
+
+// compressBody returns the gzip compressed body if compression is enabled and
+// the body reaches the threshold, it reports whether it was compressed.
+func (c *Client) compressBody(body []byte) ([]byte, bool, error) {
+	if c.compression == nil || len(body) < c.compression.Threshold {
+		return body, false, nil
+	}
+
+	buf := bytes.Buffer{}
+	writer, err := gzip.NewWriterLevel(&buf, c.compression.Level)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if _, err := writer.Write(body); err != nil {
+		return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+	}
+
+	return buf.Bytes(), true, nil
+}
+
+// gzipBody is a response body decoded while it is read.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Close closes the decoder and the compressed body.
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// decodeResponse replaces the body of a gzip compressed response with its
+// decoded content, the same way the default transport does when it asks for
+// compression itself.
+func decodeResponse(response *http.Response) error {
+	if !strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+
+	reader, err := gzip.NewReader(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	response.Body = &gzipBody{Reader: reader, body: response.Body}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+	return nil
+}
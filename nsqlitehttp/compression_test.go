@@ -0,0 +1,129 @@
+package nsqlitehttp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	const okBody = `{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],"rows":[[1]]}]}`
+
+	compressedRequests := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+		compressed := r.Header.Get("Content-Encoding") == "gzip"
+		if compressed {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = reader
+		}
+		compressedRequests <- compressed
+
+		queries := []Query{}
+		if err := json.NewDecoder(body).Decode(&queries); err != nil || len(queries) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.Write([]byte(okBody))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		writer.Write([]byte(okBody))
+		writer.Close()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCompression(Compression{
+		Level:     gzip.BestSpeed,
+		Threshold: 100,
+	}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		wantCompressed bool
+	}{
+		{
+			name:           "Small batch is not compressed",
+			query:          "SELECT 1",
+			wantCompressed: false,
+		},
+		{
+			name:           "Large batch is compressed",
+			query:          "SELECT 1 -- " + strings.Repeat("x", 100),
+			wantCompressed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := []Query{{Query: tt.query}}
+
+			responses, err := client.SendQueries(context.Background(), queries)
+			if err != nil {
+				t.Fatalf("failed to send queries: %v", err)
+			}
+			if got := <-compressedRequests; got != tt.wantCompressed {
+				t.Errorf("expected compressed request: %v, got: %v", tt.wantCompressed, got)
+			}
+			if len(responses) != 1 || len(responses[0].Rows) != 1 {
+				t.Errorf("expected the decoded response, got: %+v", responses)
+			}
+
+			stream, err := client.StreamQueries(context.Background(), queries)
+			if err != nil {
+				t.Fatalf("failed to stream queries: %v", err)
+			}
+			defer stream.Close()
+			<-compressedRequests
+
+			if _, err := stream.NextResult(); err != nil {
+				t.Fatalf("failed to read result: %v", err)
+			}
+			if _, err := stream.NextRow(); err != nil {
+				t.Fatalf("failed to read row: %v", err)
+			}
+			if _, err := stream.NextRow(); !errors.Is(err, io.EOF) {
+				t.Errorf("expected io.EOF, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithCompressionValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression Compression
+		expectError bool
+	}{
+		{name: "Default", compression: DefaultCompression},
+		{name: "Best compression", compression: Compression{Level: gzip.BestCompression}},
+		{name: "Invalid level", compression: Compression{Level: 10}, expectError: true},
+		{name: "Negative threshold", compression: Compression{Threshold: -1}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient("http://localhost:9876", WithCompression(tt.compression))
+			if tt.expectError != (err != nil) {
+				t.Errorf("expected error: %v, got: %v", tt.expectError, err)
+			}
+		})
+	}
+}
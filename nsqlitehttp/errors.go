@@ -0,0 +1,443 @@
+package nsqlitehttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrorCode is an SQLite result code, either primary (such as ErrorCodeBusy)
+// or extended (such as ErrorCodeConstraintUnique).
+//
+// https://www.sqlite.org/rescode.html
+type ErrorCode int
+
+// Primary result codes.
+const (
+	ErrorCodeError      ErrorCode = 1
+	ErrorCodeInternal   ErrorCode = 2
+	ErrorCodePerm       ErrorCode = 3
+	ErrorCodeAbort      ErrorCode = 4
+	ErrorCodeBusy       ErrorCode = 5
+	ErrorCodeLocked     ErrorCode = 6
+	ErrorCodeNoMem      ErrorCode = 7
+	ErrorCodeReadOnly   ErrorCode = 8
+	ErrorCodeInterrupt  ErrorCode = 9
+	ErrorCodeIOErr      ErrorCode = 10
+	ErrorCodeCorrupt    ErrorCode = 11
+	ErrorCodeNotFound   ErrorCode = 12
+	ErrorCodeFull       ErrorCode = 13
+	ErrorCodeCantOpen   ErrorCode = 14
+	ErrorCodeProtocol   ErrorCode = 15
+	ErrorCodeEmpty      ErrorCode = 16
+	ErrorCodeSchema     ErrorCode = 17
+	ErrorCodeTooBig     ErrorCode = 18
+	ErrorCodeConstraint ErrorCode = 19
+	ErrorCodeMismatch   ErrorCode = 20
+	ErrorCodeMisuse     ErrorCode = 21
+	ErrorCodeNoLFS      ErrorCode = 22
+	ErrorCodeAuth       ErrorCode = 23
+	ErrorCodeFormat     ErrorCode = 24
+	ErrorCodeRange      ErrorCode = 25
+	ErrorCodeNotADB     ErrorCode = 26
+)
+
+// Extended result codes.
+const (
+	ErrorCodeBusyRecovery         ErrorCode = 261
+	ErrorCodeBusySnapshot         ErrorCode = 517
+	ErrorCodeBusyTimeout          ErrorCode = 773
+	ErrorCodeLockedSharedCache    ErrorCode = 262
+	ErrorCodeReadOnlyRecovery     ErrorCode = 264
+	ErrorCodeReadOnlyCantLock     ErrorCode = 520
+	ErrorCodeReadOnlyRollback     ErrorCode = 776
+	ErrorCodeReadOnlyDBMoved      ErrorCode = 1032
+	ErrorCodeConstraintCheck      ErrorCode = 275
+	ErrorCodeConstraintCommitHook ErrorCode = 531
+	ErrorCodeConstraintForeignKey ErrorCode = 787
+	ErrorCodeConstraintFunction   ErrorCode = 1043
+	ErrorCodeConstraintNotNull    ErrorCode = 1299
+	ErrorCodeConstraintPrimaryKey ErrorCode = 1555
+	ErrorCodeConstraintTrigger    ErrorCode = 1811
+	ErrorCodeConstraintUnique     ErrorCode = 2067
+	ErrorCodeConstraintVTab       ErrorCode = 2323
+	ErrorCodeConstraintRowID      ErrorCode = 2579
+)
+
+// errorCodeNames are the SQLite names of the known result codes.
+var errorCodeNames = map[ErrorCode]string{
+	ErrorCodeError:                "ERROR",
+	ErrorCodeInternal:             "INTERNAL",
+	ErrorCodePerm:                 "PERM",
+	ErrorCodeAbort:                "ABORT",
+	ErrorCodeBusy:                 "BUSY",
+	ErrorCodeLocked:               "LOCKED",
+	ErrorCodeNoMem:                "NOMEM",
+	ErrorCodeReadOnly:             "READONLY",
+	ErrorCodeInterrupt:            "INTERRUPT",
+	ErrorCodeIOErr:                "IOERR",
+	ErrorCodeCorrupt:              "CORRUPT",
+	ErrorCodeNotFound:             "NOTFOUND",
+	ErrorCodeFull:                 "FULL",
+	ErrorCodeCantOpen:             "CANTOPEN",
+	ErrorCodeProtocol:             "PROTOCOL",
+	ErrorCodeEmpty:                "EMPTY",
+	ErrorCodeSchema:               "SCHEMA",
+	ErrorCodeTooBig:               "TOOBIG",
+	ErrorCodeConstraint:           "CONSTRAINT",
+	ErrorCodeMismatch:             "MISMATCH",
+	ErrorCodeMisuse:               "MISUSE",
+	ErrorCodeNoLFS:                "NOLFS",
+	ErrorCodeAuth:                 "AUTH",
+	ErrorCodeFormat:               "FORMAT",
+	ErrorCodeRange:                "RANGE",
+	ErrorCodeNotADB:               "NOTADB",
+	ErrorCodeBusyRecovery:         "BUSY_RECOVERY",
+	ErrorCodeBusySnapshot:         "BUSY_SNAPSHOT",
+	ErrorCodeBusyTimeout:          "BUSY_TIMEOUT",
+	ErrorCodeLockedSharedCache:    "LOCKED_SHAREDCACHE",
+	ErrorCodeReadOnlyRecovery:     "READONLY_RECOVERY",
+	ErrorCodeReadOnlyCantLock:     "READONLY_CANTLOCK",
+	ErrorCodeReadOnlyRollback:     "READONLY_ROLLBACK",
+	ErrorCodeReadOnlyDBMoved:      "READONLY_DBMOVED",
+	ErrorCodeConstraintCheck:      "CONSTRAINT_CHECK",
+	ErrorCodeConstraintCommitHook: "CONSTRAINT_COMMITHOOK",
+	ErrorCodeConstraintForeignKey: "CONSTRAINT_FOREIGNKEY",
+	ErrorCodeConstraintFunction:   "CONSTRAINT_FUNCTION",
+	ErrorCodeConstraintNotNull:    "CONSTRAINT_NOTNULL",
+	ErrorCodeConstraintPrimaryKey: "CONSTRAINT_PRIMARYKEY",
+	ErrorCodeConstraintTrigger:    "CONSTRAINT_TRIGGER",
+	ErrorCodeConstraintUnique:     "CONSTRAINT_UNIQUE",
+	ErrorCodeConstraintVTab:       "CONSTRAINT_VTAB",
+	ErrorCodeConstraintRowID:      "CONSTRAINT_ROWID",
+}
+
+// String returns the SQLite name of the code, such as "CONSTRAINT_UNIQUE".
+func (c ErrorCode) String() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return "CODE_" + strconv.Itoa(int(c))
+}
+
+// Primary returns the primary result code of an extended result code.
+func (c ErrorCode) Primary() ErrorCode {
+	return c & 0xff
+}
+
+// Error is the error returned by the Client when a request or a query fails.
+//
+// Use errors.As to access it:
+//
+//	var nsqliteErr *nsqlitehttp.Error
+//	if errors.As(err, &nsqliteErr) {
+//		fmt.Println(nsqliteErr.Code)
+//	}
+type Error struct {
+	// StatusCode is the HTTP status code of the response, 0 if no response
+	// was received.
+	StatusCode int
+	// QueryIndex is the index of the failed query in the batch, -1 if the
+	// error is not related to a specific query.
+	QueryIndex int
+	// Message is the error message, as returned by the server for failed
+	// queries.
+	Message string
+	// Code is the SQLite result code parsed from Message, 0 if unknown. It is
+	// the extended code when it can be determined, use Code.Primary to get the
+	// primary one.
+	Code ErrorCode
+	// Err is the underlying error, such as a network error, if any.
+	Err error
+	// RetryAfter is the delay requested by the Retry-After header of the
+	// response, 0 if not present.
+	RetryAfter time.Duration
+
+	// notSent is true when the request provably never reached the server.
+	notSent bool
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RequestNotSent reports whether the request provably never reached the
+// server, so sending it again can not execute its queries twice.
+func (e *Error) RequestNotSent() bool {
+	return e.notSent
+}
+
+// newQueryError creates the error of a failed query.
+func newQueryError(index int, message string) *Error {
+	return &Error{
+		StatusCode: http.StatusOK,
+		QueryIndex: index,
+		Message:    message,
+		Code:       parseErrorCode(message),
+	}
+}
+
+// newStatusError creates the error of a response with an unwanted status
+// code, message is the response body, if any.
+func newStatusError(response *http.Response, message string) *Error {
+	if response.StatusCode == http.StatusUnauthorized {
+		return &Error{
+			StatusCode: response.StatusCode,
+			QueryIndex: -1,
+			Message:    "authentication failed, please check your credentials",
+		}
+	}
+
+	message = strings.TrimSpace(message)
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+	if message == "" {
+		message = "unwanted response status: " + response.Status
+	} else {
+		message = "unwanted response status: " + response.Status + ": " + message
+	}
+
+	return &Error{
+		StatusCode: response.StatusCode,
+		QueryIndex: -1,
+		Message:    message,
+		Code:       parseErrorCode(message),
+		RetryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		// The server, or a proxy in front of it, refused to handle the
+		// request.
+		notSent: response.StatusCode == http.StatusServiceUnavailable ||
+			response.StatusCode == http.StatusTooManyRequests,
+	}
+}
+
+// newRequestError creates the error of a request that did not get a
+// response.
+func newRequestError(err error) *Error {
+	return &Error{
+		QueryIndex: -1,
+		Message:    "failed to send request",
+		Err:        err,
+		notSent:    isDialError(err),
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in
+// seconds or as an HTTP date. It returns 0 if the value is empty or invalid.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// isDialError reports whether err happened while establishing the
+// connection, before anything was written to the server.
+func isDialError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// errorCodeSuffix matches the numeric result code appended by the server to
+// the error messages, such as "constraint failed: UNIQUE constraint failed:
+// t.id (2067)".
+var errorCodeSuffix = regexp.MustCompile(`^(.+) \((\d+)\)$`)
+
+// errorCodeStrings are the generic SQLite messages of the primary result
+// codes, as returned by sqlite3_errstr. The server starts its error messages
+// with the one of the code it appends.
+var errorCodeStrings = map[ErrorCode]string{
+	ErrorCodeError:      "SQL logic error",
+	ErrorCodePerm:       "access permission denied",
+	ErrorCodeAbort:      "query aborted",
+	ErrorCodeBusy:       "database is locked",
+	ErrorCodeLocked:     "database table is locked",
+	ErrorCodeNoMem:      "out of memory",
+	ErrorCodeReadOnly:   "attempt to write a readonly database",
+	ErrorCodeInterrupt:  "interrupted",
+	ErrorCodeIOErr:      "disk I/O error",
+	ErrorCodeCorrupt:    "database disk image is malformed",
+	ErrorCodeNotFound:   "unknown operation",
+	ErrorCodeFull:       "database or disk is full",
+	ErrorCodeCantOpen:   "unable to open database file",
+	ErrorCodeProtocol:   "locking protocol",
+	ErrorCodeSchema:     "database schema has changed",
+	ErrorCodeTooBig:     "string or blob too big",
+	ErrorCodeConstraint: "constraint failed",
+	ErrorCodeMismatch:   "datatype mismatch",
+	ErrorCodeMisuse:     "bad parameter or other API misuse",
+	ErrorCodeAuth:       "authorization denied",
+	ErrorCodeRange:      "column index out of range",
+	ErrorCodeNotADB:     "file is not a database",
+}
+
+// errorCodeMessages maps well known SQLite error messages to their codes,
+// they are checked in order.
+var errorCodeMessages = []struct {
+	substr string
+	code   ErrorCode
+}{
+	{"UNIQUE constraint failed", ErrorCodeConstraintUnique},
+	{"NOT NULL constraint failed", ErrorCodeConstraintNotNull},
+	{"FOREIGN KEY constraint failed", ErrorCodeConstraintForeignKey},
+	{"CHECK constraint failed", ErrorCodeConstraintCheck},
+	{"constraint failed", ErrorCodeConstraint},
+	{"database table is locked", ErrorCodeLocked},
+	{"database schema is locked", ErrorCodeLocked},
+	{"database is locked", ErrorCodeBusy},
+	{"attempt to write a readonly database", ErrorCodeReadOnly},
+	{"database or disk is full", ErrorCodeFull},
+	{"interrupted", ErrorCodeInterrupt},
+	{"datatype mismatch", ErrorCodeMismatch},
+	{"string or blob too big", ErrorCodeTooBig},
+	{"too many SQL variables", ErrorCodeError},
+	{"column index out of range", ErrorCodeRange},
+	{"database disk image is malformed", ErrorCodeCorrupt},
+	{"file is not a database", ErrorCodeNotADB},
+	{"no such table", ErrorCodeError},
+	{"no such column", ErrorCodeError},
+	{"syntax error", ErrorCodeError},
+	{"SQL logic error", ErrorCodeError},
+}
+
+// parseErrorCode returns the SQLite result code of an error message, 0 if it
+// can not be determined.
+func parseErrorCode(message string) ErrorCode {
+	if code, ok := parseErrorCodeSuffix(message); ok {
+		return code
+	}
+
+	for _, m := range errorCodeMessages {
+		if strings.Contains(message, m.substr) {
+			return m.code
+		}
+	}
+
+	return 0
+}
+
+// parseErrorCodeSuffix returns the result code appended to an error message
+// of the server. The code is only trusted when the message starts with the
+// generic message of its primary code, so numbers ending the detail of the
+// error, such as "CHECK constraint failed: status IN (5)", are ignored.
+func parseErrorCodeSuffix(message string) (ErrorCode, bool) {
+	match := errorCodeSuffix.FindStringSubmatch(message)
+	if match == nil {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(match[2])
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	code := ErrorCode(number)
+
+	str, ok := errorCodeStrings[code.Primary()]
+	if !ok || (match[1] != str && !strings.HasPrefix(match[1], str+": ")) {
+		return 0, false
+	}
+	return code, true
+}
+
+// asError returns the *Error in the chain of err, if any.
+func asError(err error) (*Error, bool) {
+	var nsqliteErr *Error
+	ok := errors.As(err, &nsqliteErr)
+	return nsqliteErr, ok
+}
+
+// hasPrimaryCode reports whether err is an *Error with the given primary
+// result code.
+func hasPrimaryCode(err error, code ErrorCode) bool {
+	nsqliteErr, ok := asError(err)
+	return ok && nsqliteErr.Code.Primary() == code
+}
+
+// IsConstraintViolation reports whether err is a failed constraint, such as
+// UNIQUE, NOT NULL, CHECK or FOREIGN KEY.
+func IsConstraintViolation(err error) bool {
+	return hasPrimaryCode(err, ErrorCodeConstraint)
+}
+
+// IsBusy reports whether err is caused by the database being busy (locked by
+// another connection).
+func IsBusy(err error) bool {
+	return hasPrimaryCode(err, ErrorCodeBusy) || hasPrimaryCode(err, ErrorCodeLocked)
+}
+
+// IsReadOnly reports whether err is caused by writing to a read-only
+// database.
+func IsReadOnly(err error) bool {
+	return hasPrimaryCode(err, ErrorCodeReadOnly)
+}
+
+// IsTransport reports whether err is a transport failure: no response was
+// received from the server, or a proxy answered with 502, 503 or 504.
+func IsTransport(err error) bool {
+	nsqliteErr, ok := asError(err)
+	if !ok {
+		return false
+	}
+
+	switch nsqliteErr.StatusCode {
+	case 0:
+		return nsqliteErr.Err != nil
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsRequestNotSent reports whether err is an *Error of a request that
+// provably never reached the server, see Error.RequestNotSent.
+func IsRequestNotSent(err error) bool {
+	nsqliteErr, ok := asError(err)
+	return ok && nsqliteErr.RequestNotSent()
+}
+
+// IsAuth reports whether err is caused by the server rejecting the
+// credentials.
+func IsAuth(err error) bool {
+	nsqliteErr, ok := asError(err)
+	if !ok {
+		return false
+	}
+	return nsqliteErr.StatusCode == http.StatusUnauthorized ||
+		nsqliteErr.StatusCode == http.StatusForbidden ||
+		nsqliteErr.Code.Primary() == ErrorCodeAuth
+}
@@ -0,0 +1,128 @@
+package nsqlitehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+)
+
+func TestParseErrorCode(t *testing.T) {
+	tests := []struct {
+		message string
+		want    ErrorCode
+	}{
+		{message: "UNIQUE constraint failed: users.email", want: ErrorCodeConstraintUnique},
+		{message: "NOT NULL constraint failed: users.name", want: ErrorCodeConstraintNotNull},
+		{message: "FOREIGN KEY constraint failed", want: ErrorCodeConstraintForeignKey},
+		{message: "CHECK constraint failed: age > 0", want: ErrorCodeConstraintCheck},
+		{
+			message: "constraint failed: UNIQUE constraint failed: t.id (1555)",
+			want:    ErrorCodeConstraintPrimaryKey,
+		},
+		{message: "database is locked (5)", want: ErrorCodeBusy},
+		{message: "CHECK constraint failed: status IN (5)", want: ErrorCodeConstraintCheck},
+		{
+			message: "constraint failed: CHECK constraint failed: status IN (5) (275)",
+			want:    ErrorCodeConstraintCheck,
+		},
+		{message: "SQL logic error: no such table: t (1)", want: ErrorCodeError},
+		{message: "UNIQUE constraint failed: t.id (2067)", want: ErrorCodeConstraintUnique},
+		{message: "syntax error near (6)", want: ErrorCodeError},
+		{message: "database is locked", want: ErrorCodeBusy},
+		{message: "database table is locked", want: ErrorCodeLocked},
+		{message: "attempt to write a readonly database", want: ErrorCodeReadOnly},
+		{message: "no such table: users", want: ErrorCodeError},
+		{message: `near "SELEC": syntax error`, want: ErrorCodeError},
+		{message: "something unexpected", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := parseErrorCode(tt.message); got != tt.want {
+				t.Errorf("parseErrorCode(%q) = %v, want %v", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if got := ErrorCodeConstraintUnique.Primary(); got != ErrorCodeConstraint {
+		t.Errorf("Primary() = %v, want %v", got, ErrorCodeConstraint)
+	}
+	if got := ErrorCodeConstraintUnique.String(); got != "CONSTRAINT_UNIQUE" {
+		t.Errorf("String() = %q, want %q", got, "CONSTRAINT_UNIQUE")
+	}
+	if got := ErrorCode(9999).String(); got != "CODE_9999" {
+		t.Errorf("String() = %q, want %q", got, "CODE_9999")
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	unique := fmt.Errorf("failed to execute query: %w", newQueryError(1, "UNIQUE constraint failed: t.x"))
+	busy := newQueryError(0, "database is locked")
+	auth := newStatusError(&http.Response{StatusCode: http.StatusUnauthorized}, "")
+	transport := newRequestError(errors.New("connection refused"))
+	dial := newRequestError(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+	unavailable := newStatusError(&http.Response{StatusCode: http.StatusServiceUnavailable}, "")
+	badGateway := newStatusError(&http.Response{StatusCode: http.StatusBadGateway}, "")
+
+	var nsqliteErr *Error
+	if !errors.As(unique, &nsqliteErr) {
+		t.Fatalf("expected errors.As to find *Error")
+	}
+	if nsqliteErr.QueryIndex != 1 || nsqliteErr.StatusCode != http.StatusOK {
+		t.Errorf("unexpected error fields: %+v", nsqliteErr)
+	}
+
+	tests := []struct {
+		name string
+		fn   func(error) bool
+		err  error
+		want bool
+	}{
+		{name: "Constraint violation", fn: IsConstraintViolation, err: unique, want: true},
+		{name: "Busy is not a constraint violation", fn: IsConstraintViolation, err: busy, want: false},
+		{name: "Busy", fn: IsBusy, err: busy, want: true},
+		{name: "Auth", fn: IsAuth, err: auth, want: true},
+		{name: "Transport is not auth", fn: IsAuth, err: transport, want: false},
+		{name: "Transport", fn: IsTransport, err: transport, want: true},
+		{name: "Bad gateway is transport", fn: IsTransport, err: badGateway, want: true},
+		{name: "Busy is not transport", fn: IsTransport, err: busy, want: false},
+		{name: "Dial not sent", fn: IsRequestNotSent, err: dial, want: true},
+		{name: "Unavailable not sent", fn: IsRequestNotSent, err: unavailable, want: true},
+		{name: "Unknown transport may be sent", fn: IsRequestNotSent, err: transport, want: false},
+		{name: "Bad gateway may be sent", fn: IsRequestNotSent, err: badGateway, want: false},
+		{name: "Plain error", fn: IsBusy, err: errors.New("database is locked"), want: false},
+		{name: "Nil error", fn: IsConstraintViolation, err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryResponseErr(t *testing.T) {
+	ok := QueryResponse{Type: QueryResponseTypeRead}
+	if err := ok.Err(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+
+	failed := QueryResponse{Type: QueryResponseTypeError, Error: "database is locked", index: 2}
+	err := failed.Err()
+	if !IsBusy(err) {
+		t.Errorf("expected busy error, got: %v", err)
+	}
+	if nsqliteErr, _ := asError(err); nsqliteErr.QueryIndex != 2 {
+		t.Errorf("expected query index 2, got: %d", nsqliteErr.QueryIndex)
+	}
+	if err.Error() != "database is locked" {
+		t.Errorf("unexpected message: %q", err.Error())
+	}
+}
@@ -0,0 +1,237 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/internal/nsqlitelex"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+)
+
+// ErrTxEndpointDown is returned when a query of a transaction is sent while
+// the server that started the transaction is down. Transactions are never
+// moved to another server, every query of the transaction fails with this
+// error until a COMMIT or ROLLBACK ends it.
+var ErrTxEndpointDown = errors.New("server of the transaction is down")
+
+// ErrPrimaryDown is returned when a write or a transaction is sent while the
+// primary is down. They are never sent to the replicas, which only serve
+// reads.
+var ErrPrimaryDown = errors.New("primary server is down")
+
+// DefaultProbeInterval is the default interval of the background health
+// checks of a multi-host client.
+const DefaultProbeInterval = 5 * time.Second
+
+// endpoint is a server of the connection string and its health.
+type endpoint struct {
+	addr nsqlitedsn.Address
+	down atomic.Bool
+}
+
+// failover tracks the health of the servers of a multi-host client and the
+// server each transaction is pinned to.
+type failover struct {
+	// endpoints are the servers of the connection string, the primary first.
+	endpoints []*endpoint
+	// txEndpoints maps the ID of every open transaction to the endpoint that
+	// started it.
+	txEndpoints sync.Map
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+// WithProbeInterval sets the interval of the background health checks that
+// detect down servers and bring them back once they recover. It only applies
+// to connection strings with several hosts. Default is DefaultProbeInterval,
+// 0 disables the background health checks, a down server then comes back
+// once a request sent to it succeeds.
+func WithProbeInterval(interval time.Duration) ClientOption {
+	return func(c *Client) error {
+		if interval < 0 {
+			return errors.New("probe interval must not be negative")
+		}
+		c.probeInterval = interval
+		return nil
+	}
+}
+
+// newFailover creates the failover state of the given servers.
+func newFailover(addresses []nsqlitedsn.Address) *failover {
+	f := &failover{stop: make(chan struct{})}
+	for _, addr := range addresses {
+		f.endpoints = append(f.endpoints, &endpoint{addr: addr})
+	}
+	return f
+}
+
+// endpointFor returns the endpoint of the given address, if any.
+func (f *failover) endpointFor(addr nsqlitedsn.Address) (*endpoint, bool) {
+	for _, ep := range f.endpoints {
+		if ep.addr == addr {
+			return ep, true
+		}
+	}
+	return nil, false
+}
+
+// active returns the first healthy server, or the primary if every server is
+// down.
+func (f *failover) active() nsqlitedsn.Address {
+	for _, ep := range f.endpoints {
+		if !ep.down.Load() {
+			return ep.addr
+		}
+	}
+	return f.endpoints[0].addr
+}
+
+// isDown reports whether the given server is marked as down.
+func (f *failover) isDown(addr nsqlitedsn.Address) bool {
+	ep, ok := f.endpointFor(addr)
+	return ok && ep.down.Load()
+}
+
+// txEndpoint returns the server the given transaction is pinned to, if any.
+func (f *failover) txEndpoint(txID string) (nsqlitedsn.Address, bool) {
+	addr, ok := f.txEndpoints.Load(txID)
+	if !ok {
+		return nsqlitedsn.Address{}, false
+	}
+	return addr.(nsqlitedsn.Address), true
+}
+
+// trackTx pins the transaction started by resp to addr.
+func (f *failover) trackTx(addr nsqlitedsn.Address, resp *QueryResponse) {
+	if resp.Type == QueryResponseTypeBegin && resp.TxID != "" {
+		f.txEndpoints.Store(resp.TxID, addr)
+	}
+}
+
+// untrackTx unpins the transactions ended by a COMMIT or ROLLBACK of the
+// batch. It is called once the batch was sent or rejected, whatever the
+// response, as a failed COMMIT leaves a transaction the caller can not use
+// anymore.
+func (f *failover) untrackTx(queries []Query) {
+	for _, query := range queries {
+		if query.TxID != "" && nsqlitelex.EndsTransaction(query.Query) {
+			f.txEndpoints.Delete(query.TxID)
+		}
+	}
+}
+
+// setDown marks the given server as down or healthy, it reports whether the
+// state changed.
+func (f *failover) setDown(addr nsqlitedsn.Address, down bool) bool {
+	ep, ok := f.endpointFor(addr)
+	if !ok {
+		return false
+	}
+	return ep.down.Swap(down) != down
+}
+
+// close stops the background health checks and waits for them to finish.
+func (f *failover) close() {
+	f.stopOnce.Do(func() { close(f.stop) })
+	f.stopped.Wait()
+}
+
+// markDown marks the server that failed to answer the request as down, so the
+// next requests go to another server. Errors caused by the caller canceling
+// the request are ignored.
+func (c *Client) markDown(ctx context.Context, addr nsqlitedsn.Address, err error) {
+	if c.failover == nil || ctx.Err() != nil {
+		return
+	}
+
+	nsqliteErr, ok := asError(err)
+	if !ok || nsqliteErr.StatusCode != 0 || nsqliteErr.Err == nil {
+		return
+	}
+
+	if c.failover.setDown(addr, true) && c.logger != nil {
+		c.logger.LogAttrs(
+			ctx, slog.LevelWarn, "nsqlite server down",
+			slog.String("address", addr.String()),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// markUp marks the server that answered a request as healthy.
+func (c *Client) markUp(ctx context.Context, addr nsqlitedsn.Address) {
+	if c.failover == nil {
+		return
+	}
+
+	if c.failover.setDown(addr, false) && c.logger != nil {
+		c.logger.LogAttrs(
+			ctx, slog.LevelWarn, "nsqlite server up",
+			slog.String("address", addr.String()),
+		)
+	}
+}
+
+// probe pings every server once per interval, marking them as down or healthy,
+// until the failover is closed.
+func (c *Client) probe(interval time.Duration) {
+	defer c.failover.stopped.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.failover.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, ep := range c.failover.endpoints {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := c.sendPingTo(ctx, ep.addr)
+			cancel()
+
+			if c.failover.setDown(ep.addr, err != nil) && c.logger != nil {
+				state := "nsqlite server up"
+				if err != nil {
+					state = "nsqlite server down"
+				}
+				c.logger.LogAttrs(
+					context.Background(), slog.LevelWarn, state,
+					slog.String("address", ep.addr.String()),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}
+}
+
+// newPrimaryDownError returns the error of a write or transaction sent while
+// the primary is down.
+func newPrimaryDownError(addr nsqlitedsn.Address) *Error {
+	return &Error{
+		QueryIndex: -1,
+		Message:    "failed to send request",
+		Err:        fmt.Errorf("%w: %s", ErrPrimaryDown, addr),
+		notSent:    true,
+	}
+}
+
+// newTxEndpointDownError returns the error of a query of a transaction
+// pinned to a down server.
+func newTxEndpointDownError(addr nsqlitedsn.Address) *Error {
+	return &Error{
+		QueryIndex: -1,
+		Message:    "failed to send request",
+		Err:        fmt.Errorf("%w: %s", ErrTxEndpointDown, addr),
+		notSent:    true,
+	}
+}
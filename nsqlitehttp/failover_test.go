@@ -0,0 +1,197 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+)
+
+// newFailoverServer returns a server answering every query with the given
+// body and every health check with OK.
+func newFailoverServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.Write([]byte("OK"))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestClientFailover(t *testing.T) {
+	first := newFailoverServer(`{"results":[{"type":"begin","txId":"tx1"}]}`)
+	second := newFailoverServer(`{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],"rows":[[2]]}]}`)
+	defer second.Close()
+
+	// The health checks never run during the test, but they are enabled so
+	// the down primary is not used.
+	client, err := NewClient(
+		first.URL+","+strings.TrimPrefix(second.URL, "http://"),
+		WithProbeInterval(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	resp, err := client.SendQuery(ctx, Query{Query: "BEGIN"})
+	if err != nil || resp.TxID != "tx1" {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	first.Close()
+	if _, err := client.SendQuery(ctx, Query{Query: "DELETE FROM t", TxID: "tx1"}); !IsTransport(err) {
+		t.Fatalf("expected a transport error, got: %v", err)
+	}
+	for _, query := range []string{"DELETE FROM t", "DELETE FROM t", "ROLLBACK"} {
+		if _, err := client.SendQuery(ctx, Query{Query: query, TxID: "tx1"}); !errors.Is(err, ErrTxEndpointDown) {
+			t.Errorf("%s: expected ErrTxEndpointDown, got: %v", query, err)
+		}
+	}
+	if _, ok := client.failover.txEndpoint("tx1"); ok {
+		t.Errorf("expected the rollback to unpin the transaction")
+	}
+
+	for _, query := range []string{"DELETE FROM t", "BEGIN"} {
+		if _, err := client.SendQuery(ctx, Query{Query: query}); !errors.Is(err, ErrPrimaryDown) {
+			t.Errorf("%s: expected ErrPrimaryDown, got: %v", query, err)
+		}
+	}
+
+	resp, err = client.SendQuery(ctx, Query{Query: "SELECT x FROM t"})
+	if err != nil {
+		t.Fatalf("expected the read to fail over, got: %v", err)
+	}
+	if len(resp.Rows) != 1 || fmt.Sprint(resp.Rows[0][0]) != "2" {
+		t.Errorf("expected the response of the second server, got: %+v", resp)
+	}
+
+	primaryCtx := WithReadFrom(ctx, nsqlitedsn.ReadFromPrimary)
+	if _, err := client.SendQuery(primaryCtx, Query{Query: "SELECT x FROM t"}); !errors.Is(err, ErrPrimaryDown) {
+		t.Errorf("expected ErrPrimaryDown for an explicit primary read, got: %v", err)
+	}
+}
+
+func TestClientFailoverUnpinsEndedTx(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		body   string
+		closed bool
+	}{
+		{name: "Commit", query: "COMMIT", body: `{"results":[{"type":"commit"}]}`},
+		{name: "Error response", query: "COMMIT", body: `{"results":[{"type":"error","error":"database is locked"}]}`},
+		{name: "Error status", query: "ROLLBACK", status: http.StatusInternalServerError, body: "internal error"},
+		{name: "Failed in transit", query: "COMMIT", closed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var queries []Query
+				if err := json.NewDecoder(r.Body).Decode(&queries); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if queries[0].Query == "BEGIN" {
+					w.Write([]byte(`{"results":[{"type":"begin","txId":"tx1"}]}`))
+					return
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer first.Close()
+			second := newFailoverServer(`{"results":[]}`)
+			defer second.Close()
+
+			client, err := NewClient(
+				first.URL+","+strings.TrimPrefix(second.URL, "http://"),
+				WithProbeInterval(0),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			if _, err := client.SendQuery(ctx, Query{Query: "BEGIN"}); err != nil {
+				t.Fatalf("failed to begin transaction: %v", err)
+			}
+			if _, ok := client.failover.txEndpoint("tx1"); !ok {
+				t.Fatalf("expected the transaction to be pinned")
+			}
+
+			if tt.closed {
+				first.Close()
+			}
+			client.SendQuery(ctx, Query{Query: tt.query, TxID: "tx1"})
+			if _, ok := client.failover.txEndpoint("tx1"); ok {
+				t.Errorf("expected %s to unpin the transaction", tt.query)
+			}
+		})
+	}
+}
+
+func TestClientFailoverWithoutProbe(t *testing.T) {
+	first := newFailoverServer(`{"results":[]}`)
+	first.Close()
+	second := newFailoverServer(`{"results":[]}`)
+	defer second.Close()
+
+	client, err := NewClient(
+		first.URL+","+strings.TrimPrefix(second.URL, "http://"),
+		WithProbeInterval(0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	// Without health checks the writes still go to the primary, so it can
+	// recover.
+	for range 2 {
+		_, err := client.SendQuery(context.Background(), Query{Query: "DELETE FROM t"})
+		if !IsTransport(err) || errors.Is(err, ErrPrimaryDown) {
+			t.Errorf("expected a transport error, got: %v", err)
+		}
+	}
+}
+
+func TestClientFailoverProbe(t *testing.T) {
+	first := newFailoverServer(`{"results":[]}`)
+	first.Close()
+	second := newFailoverServer(`{"results":[]}`)
+	defer second.Close()
+
+	client, err := NewClient(
+		first.URL+","+strings.TrimPrefix(second.URL, "http://"),
+		WithProbeInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for !client.failover.isDown(client.connStr.Primary()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the probe to mark the primary as down")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := client.SendPing(context.Background()); err != nil {
+		t.Errorf("expected the ping to reach the second server, got: %v", err)
+	}
+}
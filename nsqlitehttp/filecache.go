@@ -0,0 +1,65 @@
+package nsqlitehttp
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// fileCache holds a value loaded from files and loads it again when one of
+// the files changes.
+type fileCache[T any] struct {
+	paths []string
+	load  func() (T, error)
+
+	mu     sync.Mutex
+	stamp  string
+	value  T
+	loaded bool
+}
+
+// get returns the value of the files, loading it again if they changed. If
+// loading fails after a successful load, the previous value is returned, as
+// the files may be in the middle of a rotation.
+func (f *fileCache[T]) get() (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stamp, statErr := filesStamp(f.paths)
+	if statErr == nil && f.loaded && stamp == f.stamp {
+		return f.value, nil
+	}
+
+	value, err := f.load()
+	if err != nil {
+		if f.loaded {
+			return f.value, nil
+		}
+		return value, err
+	}
+
+	f.value, f.stamp, f.loaded = value, stamp, true
+	return value, nil
+}
+
+// invalidate makes the next call to get load the value again, even if the
+// files did not change.
+func (f *fileCache[T]) invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stamp = ""
+}
+
+// filesStamp returns a string that changes when one of the files is
+// modified.
+func filesStamp(paths []string) (string, error) {
+	stamp := ""
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%d/%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
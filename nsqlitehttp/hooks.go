@@ -0,0 +1,178 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"time"
+)
+
+// HookOp is the operation observed by a Hook.
+type HookOp string
+
+const (
+	// HookOpBatch is a batch of queries sent with Client.SendQueries.
+	HookOpBatch HookOp = "batch"
+	// HookOpStream is a batch of queries sent with Client.StreamQueries.
+	HookOpStream HookOp = "stream"
+	// HookOpExec is a statement executed with sql.DB.Exec and similar.
+	HookOpExec HookOp = "exec"
+	// HookOpQuery is a statement executed with sql.DB.Query and similar.
+	HookOpQuery HookOp = "query"
+	// HookOpBegin is the start of a transaction.
+	HookOpBegin HookOp = "begin"
+	// HookOpCommit is the commit of a transaction.
+	HookOpCommit HookOp = "commit"
+	// HookOpRollback is the rollback of a transaction.
+	HookOpRollback HookOp = "rollback"
+)
+
+// HookEvent describes an operation observed by a Hook.
+type HookEvent struct {
+	// Op is the observed operation.
+	Op HookOp
+	// Queries are the queries sent to the server, with their SQL, params and
+	// transaction ID. Before hooks can modify them, for example to prepend
+	// tags to the SQL.
+	Queries []Query
+	// TxID is the transaction of the operation, empty if there is none. For
+	// HookOpBegin it is set once the transaction has started.
+	TxID string
+
+	// Responses are the responses read from the server. For HookOpStream
+	// and HookOpQuery their rows are not included.
+	Responses []QueryResponse
+	// ServerTime is the execution time reported by the server, the sum of the
+	// QueryResponse.Time of every response read.
+	ServerTime time.Duration
+	// Duration is the wall time of the operation, for HookOpStream and
+	// HookOpQuery it includes reading the rows until they are closed.
+	Duration time.Duration
+	// Err is the error of the operation, if any.
+	Err error
+}
+
+// Hook is invoked around the operations of a Client or of the database/sql
+// driver, to time, log, redact or veto them centrally.
+type Hook interface {
+	// Before is called before the operation is sent to the server. The
+	// returned context is used for the operation, a nil context keeps the
+	// previous one. Returning an error aborts the operation without reaching
+	// the server.
+	Before(ctx context.Context, event *HookEvent) (context.Context, error)
+	// After is called once the operation finished, with the result fields
+	// of the event filled in. It is only called if Before was called, even
+	// when a Before hook aborted the operation.
+	After(ctx context.Context, event *HookEvent)
+}
+
+// HookChain is a Hook that calls every hook of the chain, Before hooks in
+// order and After hooks in reverse order.
+type HookChain []Hook
+
+var _ Hook = HookChain(nil)
+
+// hookChainKey is the context key of the number of hooks of a chain whose
+// Before was called, the chain is identified by its first element.
+type hookChainKey struct {
+	first *Hook
+}
+
+// Before calls Before of every hook in order, stopping at the first error.
+// The returned context must be passed to After, so After is only called on
+// the hooks whose Before was called.
+func (h HookChain) Before(ctx context.Context, event *HookEvent) (context.Context, error) {
+	if len(h) == 0 {
+		return ctx, nil
+	}
+
+	var err error
+	called := 0
+	for _, hook := range h {
+		called++
+		var hookCtx context.Context
+		hookCtx, err = hook.Before(ctx, event)
+		if hookCtx != nil {
+			ctx = hookCtx
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return context.WithValue(ctx, hookChainKey{first: &h[0]}, called), err
+}
+
+// After calls After in reverse order on every hook whose Before was called
+// with the context returned by Before.
+func (h HookChain) After(ctx context.Context, event *HookEvent) {
+	if len(h) == 0 {
+		return
+	}
+
+	called := len(h)
+	if n, ok := ctx.Value(hookChainKey{first: &h[0]}).(int); ok {
+		called = n
+	}
+	for i := called - 1; i >= 0; i-- {
+		h[i].After(ctx, event)
+	}
+}
+
+// WithHooks adds hooks called around every batch sent with SendQueries or
+// StreamQueries.
+func WithHooks(hooks ...Hook) ClientOption {
+	return func(c *Client) error {
+		c.hooks = append(c.hooks, hooks...)
+		return nil
+	}
+}
+
+// BuiltinHooks returns the hooks added by a tracer and a LogHook, nil or
+// without a Logger when logging is disabled. The client and the database/sql
+// driver run them before the other hooks, so the span encloses them.
+func BuiltinHooks(tracer Tracer, logHook *LogHook) HookChain {
+	builtin := HookChain{}
+	if tracer != nil {
+		builtin = append(builtin, &TraceHook{Tracer: tracer})
+	}
+	if logHook != nil && logHook.Logger != nil {
+		builtin = append(builtin, logHook)
+	}
+	return builtin
+}
+
+// newBatchEvent returns the event of a batch sent by the client. The hooks can
+// modify the queries, never the slice of the caller.
+func newBatchEvent(op HookOp, queries []Query) *HookEvent {
+	event := &HookEvent{Op: op, Queries: cloneQueries(queries)}
+	if len(queries) > 0 {
+		event.TxID = queries[0].TxID
+	}
+	return event
+}
+
+// finishEvent sets the result of a batch started at start and calls the After
+// hooks of the client. Without err, the error of the first failed response is
+// used.
+func (c *Client) finishEvent(ctx context.Context, event *HookEvent, start time.Time, responses []QueryResponse, err error) {
+	event.Duration = time.Since(start)
+	event.SetResponses(responses)
+	event.Err = err
+	for _, resp := range responses {
+		if event.Err != nil {
+			break
+		}
+		event.Err = resp.Err()
+	}
+	c.hooks.After(ctx, event)
+}
+
+// SetResponses sets the responses of the event and its server time, the sum
+// of their execution time.
+func (e *HookEvent) SetResponses(responses []QueryResponse) {
+	total := 0.0
+	for _, resp := range responses {
+		total += resp.Time
+	}
+	e.Responses = responses
+	e.ServerTime = time.Duration(total * float64(time.Second))
+}
@@ -0,0 +1,188 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingHook records the calls it receives and optionally modifies or
+// vetoes the operation.
+type recordingHook struct {
+	name   string
+	calls  *[]string
+	prefix string
+	veto   error
+	events []HookEvent
+}
+
+func (h *recordingHook) Before(ctx context.Context, event *HookEvent) (context.Context, error) {
+	*h.calls = append(*h.calls, "before "+h.name)
+	for i := range event.Queries {
+		event.Queries[i].Query = h.prefix + event.Queries[i].Query
+	}
+	return ctx, h.veto
+}
+
+func (h *recordingHook) After(_ context.Context, event *HookEvent) {
+	*h.calls = append(*h.calls, "after "+h.name)
+	h.events = append(h.events, *event)
+}
+
+func TestClientHooks(t *testing.T) {
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries := []Query{}
+		json.NewDecoder(r.Body).Decode(&queries)
+		for _, q := range queries {
+			received = append(received, q.Query)
+		}
+		w.Write([]byte(`{"results":[{"type":"write","time":0.25,"rowsAffected":1}]}`))
+	}))
+	defer server.Close()
+
+	calls := []string{}
+	first := &recordingHook{name: "first", calls: &calls, prefix: "/* app */ "}
+	second := &recordingHook{name: "second", calls: &calls}
+
+	client, err := NewClient(server.URL, WithHooks(first, second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	queries := []Query{{Query: "DELETE FROM t"}}
+	_, err = client.SendQueries(context.Background(), queries)
+	if err != nil {
+		t.Fatalf("failed to send query: %v", err)
+	}
+	if queries[0].Query != "DELETE FROM t" {
+		t.Errorf("expected the queries of the caller to be unchanged, got %q", queries[0].Query)
+	}
+
+	wantCalls := "before first,before second,after second,after first"
+	if got := strings.Join(calls, ","); got != wantCalls {
+		t.Errorf("expected calls %q, got %q", wantCalls, got)
+	}
+	if len(received) != 1 || received[0] != "/* app */ DELETE FROM t" {
+		t.Errorf("expected the modified query to reach the server, got: %v", received)
+	}
+
+	event := first.events[0]
+	if event.Op != HookOpBatch || event.ServerTime.Seconds() != 0.25 || event.Err != nil {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	veto := errors.New("vetoed")
+	second.veto = veto
+	received = nil
+	if _, err := client.SendQuery(context.Background(), Query{Query: "DELETE FROM t"}); !errors.Is(err, veto) {
+		t.Errorf("expected the veto error, got: %v", err)
+	}
+	if len(received) != 0 {
+		t.Errorf("expected a vetoed query not to reach the server, got: %v", received)
+	}
+	if got := first.events[1].Err; !errors.Is(got, veto) {
+		t.Errorf("expected the After hook to receive the veto error, got: %v", got)
+	}
+}
+
+// nilContextHook vetoes every operation returning a nil context.
+type nilContextHook struct{}
+
+func (nilContextHook) Before(context.Context, *HookEvent) (context.Context, error) {
+	return nil, errors.New("vetoed")
+}
+
+func (nilContextHook) After(context.Context, *HookEvent) {}
+
+func TestHookChainVeto(t *testing.T) {
+	calls := []string{}
+	first := &recordingHook{name: "first", calls: &calls}
+	last := &recordingHook{name: "last", calls: &calls}
+	tracer := &fakeTracer{}
+	chain := HookChain{&TraceHook{Tracer: tracer}, first, nilContextHook{}, last}
+
+	event := &HookEvent{Op: HookOpBatch}
+	ctx, err := chain.Before(context.Background(), event)
+	if err == nil {
+		t.Fatalf("expected the veto error")
+	}
+	if ctx == nil {
+		t.Fatalf("expected the last non-nil context")
+	}
+	chain.After(ctx, event)
+
+	wantCalls := "before first,after first"
+	if got := strings.Join(calls, ","); got != wantCalls {
+		t.Errorf("expected calls %q, got %q", wantCalls, got)
+	}
+	if len(tracer.spans) != 1 || !tracer.spans[0].ended {
+		t.Errorf("expected the span to end, got: %+v", tracer.spans)
+	}
+}
+
+func TestClientStreamHooks(t *testing.T) {
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries := []Query{}
+		json.NewDecoder(r.Body).Decode(&queries)
+		for _, q := range queries {
+			received = append(received, q.Query)
+		}
+		w.Write([]byte(`{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],` +
+			`"rows":[[1],[2]],"time":0.5}]}`))
+	}))
+	defer server.Close()
+
+	calls := []string{}
+	hook := &recordingHook{name: "hook", calls: &calls, prefix: "/* app */ "}
+	tracer := &fakeTracer{}
+	client, err := NewClient(server.URL, WithTracer(tracer), WithHooks(hook))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	queries := []Query{{Query: "SELECT x FROM t"}}
+	stream, err := client.StreamQueries(context.Background(), queries)
+	if err != nil {
+		t.Fatalf("failed to stream queries: %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "before hook" {
+		t.Errorf("expected After to wait for the stream to close, got calls %q", got)
+	}
+	if _, err := stream.NextResult(); err != nil {
+		t.Fatalf("failed to read result: %v", err)
+	}
+	for {
+		if _, err := stream.NextRow(); err != nil {
+			break
+		}
+	}
+	stream.Close()
+	stream.Close()
+
+	if got := strings.Join(calls, ","); got != "before hook,after hook" {
+		t.Errorf("expected calls %q, got %q", "before hook,after hook", got)
+	}
+	if len(received) != 1 || received[0] != "/* app */ SELECT x FROM t" {
+		t.Errorf("expected the modified query to reach the server, got: %v", received)
+	}
+	if queries[0].Query != "SELECT x FROM t" {
+		t.Errorf("expected the queries of the caller to be unchanged, got %q", queries[0].Query)
+	}
+
+	event := hook.events[0]
+	if event.Op != HookOpStream || len(event.Responses) != 1 || event.Err != nil {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.ServerTime.Seconds() != 0.5 {
+		t.Errorf("expected a server time of 0.5s, got %v", event.ServerTime)
+	}
+	if len(tracer.spans) != 1 || tracer.spans[0].name != "nsqlite.stream" || !tracer.spans[0].ended {
+		t.Errorf("expected an ended nsqlite.stream span, got: %+v", tracer.spans)
+	}
+}
@@ -0,0 +1,160 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// LogHook is a Hook that logs every operation with log/slog.
+//
+// Operations are logged at debug level, failed ones at error level and the
+// ones whose server execution time exceeds SlowQueryThreshold at warn level.
+// Query parameters are redacted unless LogParams is set, auth tokens are
+// never logged.
+type LogHook struct {
+	// Logger receives the log records.
+	Logger *slog.Logger
+	// SlowQueryThreshold is the server execution time from which an
+	// operation is logged as slow, 0 disables it.
+	SlowQueryThreshold time.Duration
+	// LogParams logs the values of the query parameters instead of redacting
+	// them.
+	LogParams bool
+}
+
+var _ Hook = (*LogHook)(nil)
+
+// Before does nothing, operations are logged once they finished.
+func (h *LogHook) Before(ctx context.Context, _ *HookEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+// After logs the finished operation.
+func (h *LogHook) After(ctx context.Context, event *HookEvent) {
+	level := slog.LevelDebug
+	message := "nsqlite " + string(event.Op)
+	slow := h.SlowQueryThreshold > 0 && event.ServerTime >= h.SlowQueryThreshold
+	switch {
+	case event.Err != nil:
+		level = slog.LevelError
+		message += " failed"
+	case slow:
+		level = slog.LevelWarn
+		message = "nsqlite slow " + string(event.Op)
+	}
+
+	if !h.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("op", string(event.Op)),
+		slog.Any("queries", h.queriesValue(event.Queries)),
+		slog.Duration("duration", event.Duration),
+		slog.Duration("server_time", event.ServerTime),
+	}
+	if event.TxID != "" {
+		attrs = append(attrs, slog.String("tx_id", event.TxID))
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.Any("error", event.Err))
+	}
+
+	h.Logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// queriesValue returns the loggable representation of queries, with the
+// parameters redacted unless LogParams is set.
+func (h *LogHook) queriesValue(queries []Query) []map[string]any {
+	values := make([]map[string]any, len(queries))
+	for i, query := range queries {
+		value := map[string]any{"sql": query.Query}
+		if len(query.Params) > 0 {
+			if h.LogParams {
+				value["params"] = query.Params
+			} else {
+				value["params"] = redactedParams(query.Params)
+			}
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// redactedParams returns the names of params with their values redacted.
+func redactedParams(params []QueryParam) []string {
+	redacted := make([]string, len(params))
+	for i, param := range params {
+		name := param.Name
+		if name == "" {
+			name = "?"
+		}
+		redacted[i] = name + "=[REDACTED]"
+	}
+	return redacted
+}
+
+// WithLogger logs the lifecycle of the requests, the retries and every batch
+// sent with SendQueries, see LogHook. Default is no logging.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithSlowQueryThreshold logs at warn level the batches whose server
+// execution time exceeds threshold. It requires WithLogger.
+func WithSlowQueryThreshold(threshold time.Duration) ClientOption {
+	return func(c *Client) error {
+		c.slowQueryThreshold = threshold
+		return nil
+	}
+}
+
+// WithLogParams logs the values of the query parameters instead of
+// redacting them. It requires WithLogger.
+func WithLogParams(logParams bool) ClientOption {
+	return func(c *Client) error {
+		c.logParams = logParams
+		return nil
+	}
+}
+
+// roundTrip sends the request, logging its lifecycle, and decodes the
+// compressed response.
+func (c *Client) roundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := c.httpc.Do(request)
+	if err != nil {
+		err := newRequestError(err)
+		if c.logger != nil {
+			c.logger.LogAttrs(
+				request.Context(), slog.LevelDebug, "nsqlite request failed",
+				slog.String("method", request.Method),
+				slog.String("path", request.URL.Path),
+				slog.Duration("duration", time.Since(start)),
+				slog.Any("error", err),
+			)
+		}
+		return nil, err
+	}
+
+	if c.logger != nil {
+		c.logger.LogAttrs(
+			request.Context(), slog.LevelDebug, "nsqlite request",
+			slog.String("method", request.Method),
+			slog.String("path", request.URL.Path),
+			slog.Int("status", response.StatusCode),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+
+	if err := decodeResponse(response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	return response, nil
+}
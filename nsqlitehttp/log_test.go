@@ -0,0 +1,109 @@
+package nsqlitehttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogHook(t *testing.T) {
+	query := Query{
+		Query:  "SELECT * FROM users WHERE email = :email",
+		Params: []QueryParam{{Name: ":email", Value: "secret@example.com"}},
+		TxID:   "tx1",
+	}
+
+	tests := []struct {
+		name      string
+		hook      LogHook
+		event     HookEvent
+		contains  []string
+		excludes  []string
+		wantLevel string
+	}{
+		{
+			name:      "Redacted params",
+			event:     HookEvent{Op: HookOpQuery, Queries: []Query{query}, TxID: "tx1"},
+			contains:  []string{`"msg":"nsqlite query"`, `:email=[REDACTED]`, `"tx_id":"tx1"`},
+			excludes:  []string{"secret@example.com"},
+			wantLevel: "DEBUG",
+		},
+		{
+			name:      "Logged params",
+			hook:      LogHook{LogParams: true},
+			event:     HookEvent{Op: HookOpQuery, Queries: []Query{query}},
+			contains:  []string{"secret@example.com"},
+			wantLevel: "DEBUG",
+		},
+		{
+			name:      "Slow query",
+			hook:      LogHook{SlowQueryThreshold: time.Second},
+			event:     HookEvent{Op: HookOpExec, ServerTime: 2 * time.Second},
+			contains:  []string{`"msg":"nsqlite slow exec"`},
+			wantLevel: "WARN",
+		},
+		{
+			name:      "Failed operation",
+			event:     HookEvent{Op: HookOpCommit, Err: errors.New("database is locked")},
+			contains:  []string{`"msg":"nsqlite commit failed"`, `"error":"database is locked"`},
+			wantLevel: "ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tt.hook.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			tt.hook.After(context.Background(), &tt.event)
+
+			output := buf.String()
+			if !strings.Contains(output, `"level":"`+tt.wantLevel+`"`) {
+				t.Errorf("expected level %s, got: %s", tt.wantLevel, output)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(output, s) {
+					t.Errorf("expected output to contain %q, got: %s", s, output)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(output, s) {
+					t.Errorf("expected output not to contain %q, got: %s", s, output)
+				}
+			}
+		})
+	}
+}
+
+func TestClientLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"results":[{"type":"write","rowsAffected":1}]}`))
+	}))
+	defer server.Close()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClient(server.URL+"?authToken=topsecret", WithLogger(logger))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := client.SendQuery(context.Background(), Query{Query: "DELETE FROM t"}); err != nil {
+		t.Fatalf("failed to send query: %v", err)
+	}
+
+	output := buf.String()
+	for _, s := range []string{`msg="nsqlite request"`, "path=/query", "status=200", `msg="nsqlite batch"`} {
+		if !strings.Contains(output, s) {
+			t.Errorf("expected output to contain %q, got: %s", s, output)
+		}
+	}
+	if strings.Contains(output, "topsecret") {
+		t.Errorf("expected the auth token to be redacted, got: %s", output)
+	}
+}
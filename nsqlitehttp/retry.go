@@ -0,0 +1,162 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/internal/nsqlitelex"
+)
+
+// RetryPolicy configures how the queries sent with SendQueries and
+// StreamQueries are retried when the server is busy or unavailable.
+//
+// A batch is retried when:
+//
+//   - The request never reached the server, such as connection refused
+//     errors and 429 or 503 responses.
+//   - Every query of the batch is idempotent and a query failed with
+//     SQLITE_BUSY or SQLITE_LOCKED, or the request failed in transit.
+//
+// Batches sent in the context of a transaction are never retried. Transaction
+// statements, such as BEGIN or COMMIT, are never idempotent, they are only
+// retried when the request never reached the server.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// A value of 1 disables retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum delay between two attempts, the Retry-After
+	// header of the response is honored even if it is longer.
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the delay after every attempt.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of the delay that is
+	// randomized to avoid retrying in lockstep with other clients.
+	Jitter float64
+	// IsIdempotent reports whether a query can be executed more than once
+	// without changing the result. If nil, read-only queries are considered
+	// idempotent.
+	IsIdempotent func(query Query) bool
+}
+
+// DefaultRetryPolicy is a sensible RetryPolicy for most workloads.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// WithRetryPolicy enables retries with the given policy. By default failed
+// requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) error {
+		if policy.MaxAttempts < 1 {
+			return errors.New("retry policy max attempts must be at least 1")
+		}
+		if policy.InitialBackoff < 0 || policy.MaxBackoff < 0 {
+			return errors.New("retry policy backoff must not be negative")
+		}
+		if policy.Multiplier < 1 {
+			return errors.New("retry policy multiplier must be at least 1")
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			return errors.New("retry policy jitter must be between 0 and 1")
+		}
+
+		c.retryPolicy = &policy
+		return nil
+	}
+}
+
+// isIdempotent reports whether every query of the batch is idempotent.
+func (p *RetryPolicy) isIdempotent(queries []Query) bool {
+	for _, query := range queries {
+		// Sending BEGIN twice could leave an orphaned transaction holding
+		// the locks of the database.
+		if nsqlitelex.IsTransactionControl(query.Query) {
+			return false
+		}
+		if p.IsIdempotent != nil {
+			if !p.IsIdempotent(query) {
+				return false
+			}
+			continue
+		}
+		if !nsqlitelex.IsReadOnly(query.Query) {
+			return false
+		}
+	}
+	return true
+}
+
+// shouldRetry reports whether the batch can be sent again after failing with
+// the given error.
+func (p *RetryPolicy) shouldRetry(queries []Query, err error) bool {
+	for _, query := range queries {
+		if query.TxID != "" {
+			return false
+		}
+	}
+
+	if IsRequestNotSent(err) {
+		return true
+	}
+	if !IsBusy(err) && !IsTransport(err) {
+		return false
+	}
+	return p.isIdempotent(queries)
+}
+
+// backoff returns the delay before the given retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int, err error) time.Duration {
+	if nsqliteErr, ok := asError(err); ok && nsqliteErr.RetryAfter > 0 {
+		return nsqliteErr.RetryAfter
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 {
+		delay = min(delay, float64(p.MaxBackoff))
+	}
+	delay -= delay * p.Jitter * rand.Float64()
+
+	return time.Duration(delay)
+}
+
+// retry calls send until it succeeds or the retry policy of the client gives
+// up, the error of the last attempt is returned.
+func (c *Client) retry(ctx context.Context, queries []Query, send func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil || c.retryPolicy == nil {
+			return err
+		}
+		if attempt >= c.retryPolicy.MaxAttempts || !c.retryPolicy.shouldRetry(queries, err) {
+			return err
+		}
+
+		delay := c.retryPolicy.backoff(attempt, err)
+		if c.logger != nil {
+			c.logger.LogAttrs(
+				ctx, slog.LevelInfo, "nsqlite retrying request",
+				slog.Int("attempt", attempt),
+				slog.Duration("delay", delay),
+				slog.Any("error", err),
+			)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
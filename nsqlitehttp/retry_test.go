@@ -0,0 +1,179 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	const (
+		okBody   = `{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],"rows":[[1]]}]}`
+		busyBody = `{"results":[{"type":"error","error":"database is locked"}]}`
+	)
+
+	tests := []struct {
+		name         string
+		queries      []Query
+		status       int
+		failBody     string
+		wantAttempts int32
+		wantBusy     bool
+		expectError  bool
+	}{
+		{
+			name:         "Unavailable is retried",
+			queries:      []Query{{Query: "INSERT INTO t VALUES (1)"}},
+			status:       http.StatusServiceUnavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Busy read is retried",
+			queries:      []Query{{Query: "SELECT 1"}},
+			status:       http.StatusOK,
+			failBody:     busyBody,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Busy write is not retried",
+			queries:      []Query{{Query: "INSERT INTO t VALUES (1)"}},
+			status:       http.StatusOK,
+			failBody:     busyBody,
+			wantAttempts: 1,
+			wantBusy:     true,
+		},
+		{
+			name:         "Transaction is not retried",
+			queries:      []Query{{Query: "SELECT 1", TxID: "tx"}},
+			status:       http.StatusServiceUnavailable,
+			wantAttempts: 1,
+			expectError:  true,
+		},
+		{
+			name:         "Bad gateway on BEGIN is not retried",
+			queries:      []Query{{Query: "BEGIN IMMEDIATE;"}},
+			status:       http.StatusBadGateway,
+			wantAttempts: 1,
+			expectError:  true,
+		},
+		{
+			name:         "Gateway timeout on COMMIT is not retried",
+			queries:      []Query{{Query: "COMMIT"}},
+			status:       http.StatusGatewayTimeout,
+			wantAttempts: 1,
+			expectError:  true,
+		},
+		{
+			name:         "Unavailable on BEGIN is retried",
+			queries:      []Query{{Query: "BEGIN IMMEDIATE;"}},
+			status:       http.StatusServiceUnavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Bad gateway on a read is retried",
+			queries:      []Query{{Query: "SELECT 1"}},
+			status:       http.StatusBadGateway,
+			wantAttempts: 3,
+		},
+		{
+			name:         "Internal error is not retried",
+			queries:      []Query{{Query: "SELECT 1"}},
+			status:       http.StatusInternalServerError,
+			wantAttempts: 1,
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := atomic.Int32{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				// Fail the first two attempts.
+				if attempts.Add(1) <= 2 {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.failBody))
+					return
+				}
+				w.Write([]byte(okBody))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL, WithRetryPolicy(RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				Multiplier:     1,
+			}))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			responses, err := client.SendQueries(context.Background(), tt.queries)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+			if err == nil {
+				if got := IsBusy(responses[0].Err()); got != tt.wantBusy {
+					t.Errorf("expected busy response: %v, got: %v", tt.wantBusy, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	tests := []struct {
+		retry int
+		err   error
+		want  time.Duration
+	}{
+		{retry: 1, want: 100 * time.Millisecond},
+		{retry: 3, want: 400 * time.Millisecond},
+		{retry: 10, want: time.Second},
+		{retry: 1, err: &Error{RetryAfter: 5 * time.Second}, want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.backoff(tt.retry, tt.err); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for range 100 {
+		got := policy.backoff(1, nil)
+		if got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("backoff with jitter out of range: %v", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "3", want: 3 * time.Second},
+		{value: "-1", want: 0},
+		{value: "soon", want: 0},
+		{value: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
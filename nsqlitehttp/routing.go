@@ -0,0 +1,112 @@
+package nsqlitehttp
+
+import (
+	"context"
+
+	"github.com/nsqlite/nsqlitego/internal/nsqlitelex"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+)
+
+// readFromKey is the context key of the ReadFrom override.
+type readFromKey struct{}
+
+// WithReadFrom returns a copy of ctx that overrides the readFrom parameter of
+// the connection string for the batches sent with it.
+//
+// With nsqlitedsn.ReadFromPrimary the batch goes to the primary, for example
+// to read your own writes. With nsqlitedsn.ReadFromReplicas the batch goes to
+// a replica even if it is not classified as a read. Batches sent inside a
+// transaction and transaction control statements always go to the primary.
+func WithReadFrom(ctx context.Context, readFrom nsqlitedsn.ReadFrom) context.Context {
+	return context.WithValue(ctx, readFromKey{}, readFrom)
+}
+
+// readFromFromContext returns the ReadFrom stored in ctx, if any.
+func readFromFromContext(ctx context.Context) (nsqlitedsn.ReadFrom, bool) {
+	readFrom, ok := ctx.Value(readFromKey{}).(nsqlitedsn.ReadFrom)
+	return readFrom, ok
+}
+
+// queryAddress returns the server that receives the given batch.
+//
+// Reads sent outside transactions go to the healthy replicas, in round robin,
+// when the connection string has readFrom=replicas or the primary is down.
+// Batches of a transaction go to the server that started it, and everything
+// else to the primary. Writes and transactions never go to a replica, while
+// the primary is down they fail with ErrPrimaryDown.
+func (c *Client) queryAddress(ctx context.Context, queries []Query) (nsqlitedsn.Address, error) {
+	if c.failover == nil {
+		return c.connStr.Primary(), nil
+	}
+
+	for _, query := range queries {
+		if query.TxID == "" {
+			continue
+		}
+		addr, ok := c.failover.txEndpoint(query.TxID)
+		if !ok {
+			break
+		}
+		if c.failover.isDown(addr) {
+			// The transaction stays pinned until it ends, so none of its
+			// statements reaches another server.
+			c.failover.untrackTx(queries)
+			return addr, newTxEndpointDownError(addr)
+		}
+		return addr, nil
+	}
+
+	readFrom, explicit := readFromFromContext(ctx)
+	if !explicit {
+		readFrom = c.connStr.ReadFrom
+	}
+
+	for _, query := range queries {
+		if query.TxID != "" || nsqlitelex.IsTransactionControl(query.Query) {
+			return c.primaryAddress()
+		}
+		if !nsqlitelex.IsReadOnly(query.Query) && (!explicit || readFrom != nsqlitedsn.ReadFromReplicas) {
+			return c.primaryAddress()
+		}
+	}
+
+	// Reads fail over to the replicas unless the primary was explicitly
+	// requested, for example to read your own writes.
+	primaryDown := c.failover.isDown(c.connStr.Primary())
+	if readFrom != nsqlitedsn.ReadFromReplicas && (!primaryDown || explicit) {
+		return c.primaryAddress()
+	}
+
+	replicas := []nsqlitedsn.Address{}
+	for _, replica := range c.connStr.Replicas {
+		if !c.failover.isDown(replica) {
+			replicas = append(replicas, replica)
+		}
+	}
+	if len(replicas) == 0 {
+		return c.primaryAddress()
+	}
+
+	next := c.nextReplica.Add(1) - 1
+	return replicas[next%uint64(len(replicas))], nil
+}
+
+// primaryAddress returns the primary, or an ErrPrimaryDown error if it is down
+// and the background health checks will tell when it recovers. Without health
+// checks the requests are still sent to the primary, so it can recover.
+func (c *Client) primaryAddress() (nsqlitedsn.Address, error) {
+	primary := c.connStr.Primary()
+	if c.failover != nil && c.probeInterval > 0 && c.failover.isDown(primary) {
+		return primary, newPrimaryDownError(primary)
+	}
+	return primary, nil
+}
+
+// activeAddress returns the server that receives the health checks, version
+// and stats requests, the primary unless it is down.
+func (c *Client) activeAddress() nsqlitedsn.Address {
+	if c.failover == nil {
+		return c.connStr.Primary()
+	}
+	return c.failover.active()
+}
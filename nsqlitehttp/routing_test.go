@@ -0,0 +1,71 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+)
+
+func TestClientReadRouting(t *testing.T) {
+	hits := map[string]int{}
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits[name]++
+			w.Write([]byte(`{"results":[{"type":"read","columns":["x"],"types":["INTEGER"],"rows":[[1]]}]}`))
+		}))
+	}
+
+	primary := newServer("primary")
+	defer primary.Close()
+	replica1 := newServer("replica1")
+	defer replica1.Close()
+	replica2 := newServer("replica2")
+	defer replica2.Close()
+
+	hosts := []string{}
+	for _, server := range []*httptest.Server{primary, replica1, replica2} {
+		hosts = append(hosts, strings.TrimPrefix(server.URL, "http://"))
+	}
+
+	client, err := NewClient("http://" + strings.Join(hosts, ",") + "?readFrom=replicas")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	primaryCtx := WithReadFrom(context.Background(), nsqlitedsn.ReadFromPrimary)
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		query Query
+		want  string
+	}{
+		{name: "Read goes to a replica", query: Query{Query: "SELECT 1"}, want: "replica1"},
+		{name: "Replicas are used in round robin", query: Query{Query: "SELECT 1"}, want: "replica2"},
+		{name: "Write goes to the primary", query: Query{Query: "DELETE FROM t"}, want: "primary"},
+		{name: "Begin goes to the primary", query: Query{Query: "BEGIN"}, want: "primary"},
+		{name: "Transaction goes to the primary", query: Query{Query: "SELECT 1", TxID: "tx"}, want: "primary"},
+		{name: "Context override", ctx: primaryCtx, query: Query{Query: "SELECT 1"}, want: "primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(hits)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			if _, err := client.SendQuery(ctx, tt.query); err != nil {
+				t.Fatalf("failed to send query: %v", err)
+			}
+			if hits[tt.want] != 1 {
+				t.Errorf("expected the query to reach %s, got: %v", tt.want, hits)
+			}
+		})
+	}
+}
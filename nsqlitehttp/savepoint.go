@@ -0,0 +1,59 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Savepoint creates a savepoint with the given name inside the transaction
+// identified by txID.
+//
+// https://www.sqlite.org/lang_savepoint.html
+func (c *Client) Savepoint(ctx context.Context, txID string, name string) error {
+	return c.sendSavepointQuery(ctx, txID, "SAVEPOINT", name)
+}
+
+// ReleaseSavepoint releases the savepoint with the given name, and every
+// savepoint created after it, inside the transaction identified by txID.
+func (c *Client) ReleaseSavepoint(ctx context.Context, txID string, name string) error {
+	return c.sendSavepointQuery(ctx, txID, "RELEASE", name)
+}
+
+// RollbackToSavepoint reverts the transaction identified by txID to the state
+// it had when the savepoint with the given name was created. The savepoint
+// stays active after the rollback.
+func (c *Client) RollbackToSavepoint(ctx context.Context, txID string, name string) error {
+	return c.sendSavepointQuery(ctx, txID, "ROLLBACK TO", name)
+}
+
+// sendSavepointQuery sends a savepoint statement in the context of a
+// transaction.
+func (c *Client) sendSavepointQuery(ctx context.Context, txID string, command string, name string) error {
+	if txID == "" {
+		return errors.New("savepoints require an active transaction")
+	}
+	if name == "" {
+		return errors.New("savepoint name is required")
+	}
+
+	resp, err := c.SendQuery(ctx, Query{
+		Query: command + " " + QuoteIdentifier(name) + ";",
+		TxID:  txID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", command, err)
+	}
+	if err := resp.Err(); err != nil {
+		return fmt.Errorf("failed to send %s: %w", command, err)
+	}
+
+	return nil
+}
+
+// QuoteIdentifier quotes the given name so it can be safely used as an SQLite
+// identifier.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
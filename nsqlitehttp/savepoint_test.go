@@ -0,0 +1,24 @@
+package nsqlitehttp
+
+import "testing"
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Simple name", input: "sp1", want: `"sp1"`},
+		{name: "Name with spaces", input: "my savepoint", want: `"my savepoint"`},
+		{name: "Name with quotes", input: `a"b`, want: `"a""b"`},
+		{name: "Empty name", input: "", want: `""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteIdentifier(tt.input); got != tt.want {
+				t.Errorf("QuoteIdentifier(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
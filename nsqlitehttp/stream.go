@@ -0,0 +1,422 @@
+package nsqlitehttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// streamState is the position of a QueryStream inside the response body.
+type streamState int
+
+const (
+	// streamStateResults means the decoder is between two results.
+	streamStateResults streamState = iota
+	// streamStateRows means the decoder is inside the rows of a result.
+	streamStateRows
+	// streamStateDone means every result has been read.
+	streamStateDone
+)
+
+// QueryStream reads the responses of a batch of queries incrementally from the
+// HTTP response body, so the rows of large result sets are never held in
+// memory all at once.
+//
+// Results are read in order with NextResult and the rows of the current
+// result with NextRow. A QueryStream is not safe for concurrent use and must
+// be closed after use.
+type QueryStream struct {
+	body    io.ReadCloser
+	dec     *json.Decoder
+	state   streamState
+	current *QueryResponse
+
+	// buffered holds the rows of the current result when the server sends
+	// them before the columns, in which case they can not be streamed.
+	buffered [][]any
+	// bufferedIdx is the index of the next buffered row to return.
+	bufferedIdx int
+	// resultsLen is the number of results read so far.
+	resultsLen int
+	// onResult is called with every result once its fields are read, if set.
+	onResult func(resp *QueryResponse)
+	// responses are the results read so far, kept only if onClose is set.
+	responses []*QueryResponse
+	// onClose is called once when the stream is closed with the results read
+	// and the error found while reading them, if set.
+	onClose func(responses []QueryResponse, err error)
+
+	err error
+}
+
+// StreamQueries sends one or more queries to the remote server and returns a
+// QueryStream to read the responses in the same order.
+//
+// Keep in mind that the HTTP client timeout also applies to reading the
+// stream, see WithHTTPTimeout.
+//
+// The batch is sent to the primary or to a replica depending on the readFrom
+// parameter of the connection string, see WithReadFrom.
+//
+// Only the request is retried according to the retry policy of the client,
+// errors found while reading the stream are returned as is.
+//
+// The hooks of the client are called around the batch, the After hooks once
+// the stream is closed, see WithHooks.
+func (c *Client) StreamQueries(ctx context.Context, queries []Query) (*QueryStream, error) {
+	if len(c.hooks) == 0 {
+		return c.streamQueriesWithRetry(ctx, queries)
+	}
+
+	event := newBatchEvent(HookOpStream, queries)
+	ctx, err := c.hooks.Before(ctx, event)
+	if err != nil {
+		c.finishEvent(ctx, event, time.Now(), nil, err)
+		return nil, err
+	}
+
+	start := time.Now()
+	stream, err := c.streamQueriesWithRetry(ctx, event.Queries)
+	if err != nil {
+		c.finishEvent(ctx, event, start, nil, err)
+		return nil, err
+	}
+
+	stream.onClose = func(responses []QueryResponse, err error) {
+		c.finishEvent(ctx, event, start, responses, err)
+	}
+	return stream, nil
+}
+
+// streamQueriesWithRetry sends the queries according to the retry policy of
+// the client.
+func (c *Client) streamQueriesWithRetry(ctx context.Context, queries []Query) (*QueryStream, error) {
+	var stream *QueryStream
+	err := c.retry(ctx, queries, func() error {
+		var err error
+		stream, err = c.streamQueries(ctx, queries)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// streamQueries sends the queries once and returns the stream of the
+// response.
+func (c *Client) streamQueries(ctx context.Context, queries []Query) (*QueryStream, error) {
+	requestBody, err := json.Marshal(queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	requestBody, compressed, err := c.compressBody(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := c.queryAddress(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := c.newRequestTo(ctx, addr, http.MethodPost, "/query", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if compressed {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+
+	response, err := c.do(request)
+	if c.failover != nil {
+		c.failover.untrackTx(queries)
+	}
+	if err != nil {
+		c.markDown(ctx, addr, err)
+		return nil, err
+	}
+	c.markUp(ctx, addr)
+
+	if err := checkResponse(response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	stream, err := newQueryStream(response.Body)
+	if err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	if c.failover != nil {
+		stream.onResult = func(resp *QueryResponse) {
+			c.failover.trackTx(addr, resp)
+		}
+	}
+
+	return stream, nil
+}
+
+// newQueryStream creates a QueryStream that reads the given body and positions
+// it at the start of the results array.
+func newQueryStream(body io.ReadCloser) (*QueryStream, error) {
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+
+	s := &QueryStream{
+		body: body,
+		dec:  dec,
+	}
+
+	if err := s.expectDelim('{'); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for {
+		if !dec.More() {
+			return nil, fmt.Errorf("failed to decode response: results not found")
+		}
+
+		key, err := s.readKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		if key != "results" {
+			if err := s.skipValue(); err != nil {
+				return nil, fmt.Errorf("failed to decode response: %w", err)
+			}
+			continue
+		}
+
+		if err := s.expectDelim('['); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return s, nil
+	}
+}
+
+// NextResult advances the stream to the next result and returns it, any rows
+// left in the previous result are discarded. It returns io.EOF when there are
+// no more results.
+//
+// The rows of the returned response are not loaded, they must be read with
+// NextRow. Fields sent by the server after the rows are filled in the
+// returned response once every row has been read.
+func (s *QueryStream) NextResult() (*QueryResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if s.state == streamStateRows {
+		for {
+			if _, err := s.NextRow(); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, err
+			}
+		}
+	}
+
+	if s.state == streamStateDone {
+		return nil, io.EOF
+	}
+
+	if !s.dec.More() {
+		if err := s.expectDelim(']'); err != nil {
+			return nil, s.fail(err)
+		}
+		s.state = streamStateDone
+		return nil, io.EOF
+	}
+
+	if err := s.expectDelim('{'); err != nil {
+		return nil, s.fail(err)
+	}
+
+	s.current = &QueryResponse{index: s.resultsLen}
+	s.resultsLen++
+	s.buffered = nil
+	s.bufferedIdx = 0
+
+	if err := s.readFields(); err != nil {
+		return nil, s.fail(err)
+	}
+	if s.onResult != nil {
+		s.onResult(s.current)
+	}
+	if s.onClose != nil {
+		s.responses = append(s.responses, s.current)
+	}
+
+	return s.current, nil
+}
+
+// NextRow returns the next row of the current result. It returns io.EOF when
+// there are no more rows in the current result.
+func (s *QueryStream) NextRow() ([]any, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.state != streamStateRows {
+		return nil, io.EOF
+	}
+
+	if s.buffered != nil {
+		if s.bufferedIdx >= len(s.buffered) {
+			s.buffered = nil
+			s.state = streamStateResults
+			return nil, io.EOF
+		}
+		row := s.buffered[s.bufferedIdx]
+		s.bufferedIdx++
+		return row, nil
+	}
+
+	if !s.dec.More() {
+		if err := s.expectDelim(']'); err != nil {
+			return nil, s.fail(err)
+		}
+		s.state = streamStateResults
+		if err := s.readFields(); err != nil {
+			return nil, s.fail(err)
+		}
+		if s.state == streamStateRows {
+			return nil, s.fail(errors.New("duplicated rows in result"))
+		}
+		return nil, io.EOF
+	}
+
+	var row []any
+	if err := s.dec.Decode(&row); err != nil {
+		return nil, s.fail(err)
+	}
+
+	return row, nil
+}
+
+// Close discards the unread part of the response and closes the body.
+func (s *QueryStream) Close() error {
+	if s.onClose != nil {
+		responses := make([]QueryResponse, len(s.responses))
+		for i, resp := range s.responses {
+			responses[i] = *resp
+		}
+		onClose := s.onClose
+		s.onClose = nil
+		defer onClose(responses, s.err)
+	}
+
+	_, _ = io.Copy(io.Discard, s.body)
+	s.state = streamStateDone
+	if s.err == nil {
+		s.err = errors.New("stream is closed")
+	}
+	return s.body.Close()
+}
+
+// readFields reads the fields of the current result until its end or the
+// start of its rows.
+func (s *QueryStream) readFields() error {
+	for s.dec.More() {
+		key, err := s.readKey()
+		if err != nil {
+			return err
+		}
+
+		if key != "rows" {
+			var raw json.RawMessage
+			if err := s.dec.Decode(&raw); err != nil {
+				return err
+			}
+			field, err := json.Marshal(map[string]json.RawMessage{key: raw})
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(field, s.current); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Rows can only be streamed when the columns are already known,
+		// otherwise they are buffered and the rest of the result is read.
+		if s.current.Columns == nil {
+			var rows [][]any
+			if err := s.dec.Decode(&rows); err != nil {
+				return err
+			}
+			if err := s.readFields(); err != nil {
+				return err
+			}
+			s.buffered = rows
+			if s.buffered == nil {
+				s.buffered = [][]any{}
+			}
+			s.state = streamStateRows
+			return nil
+		}
+
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			continue
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("unexpected token %v for rows", tok)
+		}
+		s.state = streamStateRows
+		return nil
+	}
+
+	return s.expectDelim('}')
+}
+
+// readKey reads an object key.
+func (s *QueryStream) readKey() (string, error) {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected token %v, expected object key", tok)
+	}
+	return key, nil
+}
+
+// skipValue reads and discards the next value.
+func (s *QueryStream) skipValue() error {
+	var raw json.RawMessage
+	return s.dec.Decode(&raw)
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter.
+func (s *QueryStream) expectDelim(delim json.Delim) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if got, ok := tok.(json.Delim); !ok || got != delim {
+		return fmt.Errorf("unexpected token %v, expected %v", tok, delim)
+	}
+	return nil
+}
+
+// fail stores the error so every following call returns it.
+func (s *QueryStream) fail(err error) error {
+	s.err = fmt.Errorf("failed to decode response: %w", err)
+	s.state = streamStateDone
+	return s.err
+}
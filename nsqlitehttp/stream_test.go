@@ -0,0 +1,168 @@
+package nsqlitehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readStream reads every result and row of the given body.
+func readStream(t *testing.T, body string) ([]QueryResponse, error) {
+	t.Helper()
+
+	stream, err := newQueryStream(io.NopCloser(strings.NewReader(body)))
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	results := []QueryResponse{}
+	for {
+		resp, err := stream.NextResult()
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for {
+			row, err := stream.NextRow()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			resp.Rows = append(resp.Rows, row)
+		}
+
+		results = append(results, *resp)
+	}
+}
+
+func TestQueryStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		want        []QueryResponse
+		expectError bool
+	}{
+		{
+			name: "Read result",
+			body: `{"results":[{"type":"read","time":0.5,"columns":["id","name"],` +
+				`"types":["INTEGER","TEXT"],"rows":[[1,"a"],[2,"b"]]}]}`,
+			want: []QueryResponse{{
+				Type:    QueryResponseTypeRead,
+				Time:    0.5,
+				Columns: []string{"id", "name"},
+				Types:   []string{"INTEGER", "TEXT"},
+				Rows: [][]any{
+					{json.Number("1"), "a"},
+					{json.Number("2"), "b"},
+				},
+			}},
+		},
+		{
+			name: "Fields after rows",
+			body: `{"results":[{"columns":["id"],"rows":[[1]],"type":"read","time":1}]}`,
+			want: []QueryResponse{{
+				Type:    QueryResponseTypeRead,
+				Time:    1,
+				Columns: []string{"id"},
+				Rows:    [][]any{{json.Number("1")}},
+			}},
+		},
+		{
+			name: "Rows before columns",
+			body: `{"results":[{"type":"read","rows":[[1],[2]],"columns":["id"]}]}`,
+			want: []QueryResponse{{
+				Type:    QueryResponseTypeRead,
+				Columns: []string{"id"},
+				Rows:    [][]any{{json.Number("1")}, {json.Number("2")}},
+			}},
+		},
+		{
+			name: "Multiple results",
+			body: `{"results":[{"type":"write","rowsAffected":2,"lastInsertId":7},` +
+				`{"type":"read","columns":["n"],"rows":[]},` +
+				`{"type":"error","error":"no such table: x"}]}`,
+			want: []QueryResponse{
+				{Type: QueryResponseTypeWrite, RowsAffected: 2, LastInsertID: 7},
+				{Type: QueryResponseTypeRead, Columns: []string{"n"}, index: 1},
+				{Type: QueryResponseTypeError, Error: "no such table: x", index: 2},
+			},
+		},
+		{
+			name: "Unknown top level fields are skipped",
+			body: `{"version":"1","results":[{"type":"begin","txId":"abc"}],"extra":{}}`,
+			want: []QueryResponse{{Type: QueryResponseTypeBegin, TxID: "abc"}},
+		},
+		{
+			name: "Null rows",
+			body: `{"results":[{"type":"read","columns":["id"],"rows":null}]}`,
+			want: []QueryResponse{{Type: QueryResponseTypeRead, Columns: []string{"id"}}},
+		},
+		{
+			name:        "Missing results",
+			body:        `{"other":[]}`,
+			expectError: true,
+		},
+		{
+			name:        "Truncated body",
+			body:        `{"results":[{"type":"read","columns":["id"],"rows":[[1],`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readStream(t, tt.body)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected: %+v, got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryStreamSkipsUnreadRows(t *testing.T) {
+	body := `{"results":[{"type":"read","columns":["id"],"rows":[[1],[2],[3]]},` +
+		`{"type":"write","rowsAffected":1}]}`
+
+	stream, err := newQueryStream(io.NopCloser(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.NextResult(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := stream.NextRow(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := stream.NextResult()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Type != QueryResponseTypeWrite || resp.RowsAffected != 1 {
+		t.Errorf("unexpected second result: %+v", resp)
+	}
+
+	if _, err := stream.NextResult(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got: %v", err)
+	}
+}
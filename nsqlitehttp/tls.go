@@ -0,0 +1,265 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
+
+// WithTLSConfig sets the TLS configuration of the default NSQLite HTTP
+// transport, keeping the rest of its settings. The certificates set with
+// WithRootCAs and WithClientCertificate, or with the TLS parameters of the
+// connection string, take precedence over the matching fields of config.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) error {
+		if config == nil {
+			return errors.New("TLS config must not be nil")
+		}
+		c.tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithRootCAs verifies the server certificate with the CA certificates of the
+// given PEM file instead of the system ones. The file is read again when it
+// changes, so the CA certificates can be rotated without a restart.
+func WithRootCAs(pemFile string) ClientOption {
+	return func(c *Client) error {
+		rootCAs, err := newCertPoolFile(pemFile)
+		if err != nil {
+			return err
+		}
+		c.rootCAs = rootCAs
+		return nil
+	}
+}
+
+// WithClientCertificate sends the client certificate of the given PEM files
+// to servers that require mutual TLS. The files are read again when they
+// change, so the certificate can be rotated without a restart.
+func WithClientCertificate(certFile string, keyFile string) ClientOption {
+	return func(c *Client) error {
+		clientCert, err := newKeyPairFile(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		c.clientCert = clientCert
+		return nil
+	}
+}
+
+// applyTLS sets the TLS configuration of the client on its transport, once
+// the options are applied.
+func (c *Client) applyTLS() error {
+	if c.tlsConfig == nil && c.rootCAs == nil && c.clientCert == nil {
+		return nil
+	}
+
+	transport, ok := c.httpc.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("TLS options require an *http.Transport")
+	}
+
+	config := &tls.Config{}
+	if c.tlsConfig != nil {
+		config = c.tlsConfig.Clone()
+	}
+
+	if c.clientCert != nil {
+		config.Certificates = nil
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return c.clientCert.get()
+		}
+	}
+
+	if c.rootCAs == nil || config.InsecureSkipVerify {
+		transport.TLSClientConfig = config
+		return nil
+	}
+
+	// Connections through a proxy do not use DialTLSContext, they are
+	// verified with the CA certificates loaded when the client was created.
+	pool, err := c.rootCAs.cache.get()
+	if err != nil {
+		return err
+	}
+	config.RootCAs = pool
+	transport.TLSClientConfig = config
+	transport.DialTLSContext = c.rootCAs.dialTLS(config, transport.DialContext)
+	return nil
+}
+
+// applyConnStrTLS applies the TLS parameters of the connection string to the
+// client.
+func (c *Client) applyConnStrTLS() error {
+	if !c.connStr.HasTLSParams() {
+		return nil
+	}
+
+	c.tlsConfig = &tls.Config{
+		ServerName:         c.connStr.TLSServerName,
+		InsecureSkipVerify: c.connStr.TLSInsecureSkipVerify,
+	}
+
+	if c.connStr.TLSCA != "" {
+		rootCAs, err := newCertPoolFile(c.connStr.TLSCA)
+		if err != nil {
+			return err
+		}
+		c.rootCAs = rootCAs
+	}
+
+	if c.connStr.TLSCert != "" {
+		clientCert, err := newKeyPairFile(c.connStr.TLSCert, c.connStr.TLSKey)
+		if err != nil {
+			return err
+		}
+		c.clientCert = clientCert
+	}
+
+	return nil
+}
+
+// certPoolFile is a PEM file of CA certificates that is read again when it
+// changes.
+type certPoolFile struct {
+	cache *fileCache[*x509.CertPool]
+}
+
+// newCertPoolFile reads the CA certificates of the given PEM file.
+func newCertPoolFile(pemFile string) (*certPoolFile, error) {
+	f := &certPoolFile{cache: &fileCache[*x509.CertPool]{
+		paths: []string{pemFile},
+		load:  func() (*x509.CertPool, error) { return loadCertPool(pemFile) },
+	}}
+	if _, err := f.cache.get(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// dialTLS returns a function that dials TLS connections verified with the
+// current CA certificates. The roots of tls.Config can not change, so the
+// server certificate is verified once the handshake is done.
+func (f *certPoolFile) dialTLS(
+	config *tls.Config,
+	dial func(ctx context.Context, network string, addr string) (net.Conn, error),
+) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		// The certificate is verified against the name of the server that
+		// is dialed, IP addresses included, never against the SNI value
+		// that is empty for IP addresses.
+		serverName := config.ServerName
+		if serverName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			serverName = host
+		}
+
+		connConfig := config.Clone()
+		connConfig.ServerName = serverName
+		connConfig.InsecureSkipVerify = true
+		connConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if err := f.verify(state, serverName); err != nil {
+				return err
+			}
+			if config.VerifyConnection != nil {
+				return config.VerifyConnection(state)
+			}
+			return nil
+		}
+
+		rawConn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		conn := tls.Client(rawConn, connConfig)
+		if err := conn.HandshakeContext(ctx); err != nil {
+			rawConn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// verify verifies the certificate chain of the given connection with the
+// current CA certificates, and that it is valid for serverName.
+func (f *certPoolFile) verify(state tls.ConnectionState, serverName string) error {
+	pool, err := f.cache.get()
+	if err != nil {
+		return err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server did not send a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify server certificate: %w", err)
+	}
+	return nil
+}
+
+// keyPairFile is a client certificate and its key, read again when one of
+// their PEM files changes.
+type keyPairFile struct {
+	cache *fileCache[*tls.Certificate]
+}
+
+// newKeyPairFile reads the client certificate of the given PEM files.
+func newKeyPairFile(certFile string, keyFile string) (*keyPairFile, error) {
+	f := &keyPairFile{cache: &fileCache[*tls.Certificate]{
+		paths: []string{certFile, keyFile},
+		load: func() (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			return &cert, nil
+		},
+	}}
+	if _, err := f.cache.get(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// get returns the current client certificate.
+func (f *keyPairFile) get() (*tls.Certificate, error) {
+	return f.cache.get()
+}
+
+// loadCertPool returns a certificate pool with the PEM certificates of the
+// given file.
+func loadCertPool(pemFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(pemFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("failed to read CA certificates: no certificate found")
+	}
+	return pool, nil
+}
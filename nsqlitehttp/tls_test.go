@@ -0,0 +1,288 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert writes a self-signed certificate with the given common
+// name and its key as PEM files.
+func writeSelfSignedCert(t *testing.T, certFile string, keyFile string, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "PRIVATE KEY", keyDER)
+}
+
+// pemWrites counts the PEM files written by the tests.
+var pemWrites atomic.Int64
+
+// writePEM writes a PEM file with a modification time later than the
+// previous writes, so every write is seen as a change.
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	modTime := time.Now().Add(time.Duration(pemWrites.Add(1)) * time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("failed to change times of %s: %v", path, err)
+	}
+}
+
+func TestClientCertificateRotation(t *testing.T) {
+	commonNames := make(chan string, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commonNames <- r.TLS.PeerCertificates[0].Subject.CommonName
+		w.Write([]byte("OK"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+	writeSelfSignedCert(t, certFile, keyFile, "first")
+
+	client, err := NewClient(
+		server.URL,
+		WithTLSConfig(&tls.Config{ServerName: "example.com"}),
+		WithRootCAs(caFile),
+		WithClientCertificate(certFile, keyFile),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	for _, commonName := range []string{"first", "rotated"} {
+		if commonName != "first" {
+			writeSelfSignedCert(t, certFile, keyFile, commonName)
+			// Force a new handshake.
+			client.httpc.CloseIdleConnections()
+		}
+
+		if err := client.SendPing(context.Background()); err != nil {
+			t.Fatalf("failed to send ping: %v", err)
+		}
+		if got := <-commonNames; got != commonName {
+			t.Errorf("expected client certificate %q, got %q", commonName, got)
+		}
+	}
+}
+
+func TestRootCAsRotation(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeSelfSignedCert(t, caFile, filepath.Join(dir, "key.pem"), "other CA")
+
+	client, err := NewClient(
+		server.URL,
+		WithTLSConfig(&tls.Config{ServerName: "example.com"}),
+		WithRootCAs(caFile),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.SendPing(context.Background()); err == nil {
+		t.Fatalf("expected the server to be untrusted")
+	}
+
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+	if err := client.SendPing(context.Background()); err != nil {
+		t.Errorf("expected the rotated CA to be trusted, got: %v", err)
+	}
+}
+
+func TestTLSOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ClientOption
+	}{
+		{
+			name:    "Nil TLS config",
+			options: []ClientOption{WithTLSConfig(nil)},
+		},
+		{
+			name:    "Missing root CAs file",
+			options: []ClientOption{WithRootCAs("/does/not/exist.pem")},
+		},
+		{
+			name:    "Missing client certificate",
+			options: []ClientOption{WithClientCertificate("/does/not/exist.pem", "/key.pem")},
+		},
+		{
+			name: "Custom round tripper",
+			options: []ClientOption{
+				WithHTTPClient(&http.Client{Transport: http.NewFileTransport(http.Dir("/"))}),
+				WithTLSConfig(&tls.Config{}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient("https://example.com", tt.options...); err == nil {
+				t.Errorf("expected an error but got none")
+			}
+		})
+	}
+}
+
+// newCASignedServer starts a TLS server with a certificate for the given DNS
+// names and IP addresses, signed by a new CA written to the returned PEM file.
+func newCASignedServer(t *testing.T, dnsNames []string, ips []net.IP) (*httptest.Server, string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", caDER)
+	return server, caFile
+}
+
+func TestRootCAsHostnameVerification(t *testing.T) {
+	tests := []struct {
+		name        string
+		dnsNames    []string
+		ips         []net.IP
+		serverName  string
+		expectError bool
+	}{
+		{
+			name:        "Certificate for another host",
+			dnsNames:    []string{"other.example"},
+			expectError: true,
+		},
+		{
+			name: "Certificate for the IP address",
+			ips:  []net.IP{net.ParseIP("127.0.0.1")},
+		},
+		{
+			name:       "Certificate for the server name",
+			dnsNames:   []string{"nsqlite.internal"},
+			serverName: "nsqlite.internal",
+		},
+		{
+			name:        "Server name not in the certificate",
+			ips:         []net.IP{net.ParseIP("127.0.0.1")},
+			serverName:  "nsqlite.internal",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, caFile := newCASignedServer(t, tt.dnsNames, tt.ips)
+			defer server.Close()
+
+			for _, option := range []string{"WithRootCAs", "tlsCA"} {
+				options := []ClientOption{WithTLSConfig(&tls.Config{ServerName: tt.serverName})}
+				connStr := server.URL
+				if option == "WithRootCAs" {
+					options = append(options, WithRootCAs(caFile))
+				} else {
+					connStr += "?tlsCA=" + caFile
+				}
+
+				client, err := NewClient(connStr, options...)
+				if err != nil {
+					t.Fatalf("failed to create client: %v", err)
+				}
+				err = client.SendPing(context.Background())
+				client.Close()
+				if tt.expectError != (err != nil) {
+					t.Errorf("%s: expected error: %v, got: %v", option, tt.expectError, err)
+				}
+			}
+		})
+	}
+}
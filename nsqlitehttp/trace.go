@@ -0,0 +1,127 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"strings"
+)
+
+// Tracer starts the spans of the operations of a Client or of the
+// database/sql driver. It is implemented by adapters for a tracing SDK, so
+// this module does not depend on any.
+type Tracer interface {
+	// StartSpan starts a span with the given name and attributes as a child
+	// of the span of ctx, if any, and returns a context holding it.
+	StartSpan(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...SpanAttr)
+	// End finishes the span, err is the error of the operation, if any.
+	End(err error)
+	// TraceParent returns the W3C traceparent of the span, sent to the server
+	// in the traceparent header. An empty value disables propagation.
+	//
+	// https://www.w3.org/TR/trace-context/#traceparent-header
+	TraceParent() string
+}
+
+// SpanAttr is a key-value attribute of a Span.
+type SpanAttr struct {
+	Key   string
+	Value any
+}
+
+// Span attribute keys set by TraceHook.
+const (
+	SpanAttrDBSystem       = "db.system"
+	SpanAttrDBOperation    = "db.operation"
+	SpanAttrDBStatement    = "db.statement"
+	SpanAttrTxID           = "nsqlite.tx_id"
+	SpanAttrBatchSize      = "nsqlite.batch_size"
+	SpanAttrStatementTypes = "nsqlite.statement_types"
+	SpanAttrRowsAffected   = "nsqlite.rows_affected"
+	SpanAttrServerTime     = "nsqlite.server_time"
+)
+
+// spanKey is the context key of the current Span.
+type spanKey struct{}
+
+// contextWithSpan returns a copy of ctx holding span.
+func contextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// spanFromContext returns the Span stored in ctx, if any.
+func spanFromContext(ctx context.Context) (Span, bool) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	return span, ok
+}
+
+// TraceHook is a Hook that starts a span per operation using Tracer, named
+// "nsqlite.<op>", and propagates it to the server with the traceparent
+// header.
+type TraceHook struct {
+	Tracer Tracer
+}
+
+var _ Hook = (*TraceHook)(nil)
+
+// Before starts the span of the operation.
+func (h *TraceHook) Before(ctx context.Context, event *HookEvent) (context.Context, error) {
+	statements := make([]string, len(event.Queries))
+	for i, query := range event.Queries {
+		statements[i] = query.Query
+	}
+
+	attrs := []SpanAttr{
+		{Key: SpanAttrDBSystem, Value: "nsqlite"},
+		{Key: SpanAttrDBOperation, Value: string(event.Op)},
+		{Key: SpanAttrDBStatement, Value: strings.Join(statements, "; ")},
+		{Key: SpanAttrBatchSize, Value: len(event.Queries)},
+	}
+	if event.TxID != "" {
+		attrs = append(attrs, SpanAttr{Key: SpanAttrTxID, Value: event.TxID})
+	}
+
+	ctx, span := h.Tracer.StartSpan(ctx, "nsqlite."+string(event.Op), attrs...)
+	return contextWithSpan(ctx, span), nil
+}
+
+// After sets the result attributes of the span and ends it.
+func (h *TraceHook) After(ctx context.Context, event *HookEvent) {
+	span, ok := spanFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	types := make([]string, len(event.Responses))
+	rowsAffected := int64(0)
+	for i, resp := range event.Responses {
+		types[i] = string(resp.Type)
+		rowsAffected += resp.RowsAffected
+	}
+
+	attrs := []SpanAttr{
+		{Key: SpanAttrStatementTypes, Value: types},
+		{Key: SpanAttrRowsAffected, Value: rowsAffected},
+		{Key: SpanAttrServerTime, Value: event.ServerTime.Seconds()},
+	}
+	if event.TxID != "" {
+		attrs = append(attrs, SpanAttr{Key: SpanAttrTxID, Value: event.TxID})
+	}
+
+	span.SetAttributes(attrs...)
+	span.End(event.Err)
+}
+
+// WithTracer starts a span for every batch sent with SendQueries, see
+// TraceHook. Requests sent with a context holding a span of the tracer
+// propagate it with the traceparent header.
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *Client) error {
+		c.tracer = tracer
+		return nil
+	}
+}
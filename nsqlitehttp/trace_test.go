@@ -0,0 +1,92 @@
+package nsqlitehttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fakeTracer records the spans it starts.
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) StartSpan(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, Span) {
+	span := &fakeSpan{name: name, attrs: map[string]any{}}
+	span.SetAttributes(attrs...)
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+// fakeSpan records its attributes and whether it ended.
+type fakeSpan struct {
+	name  string
+	attrs map[string]any
+	ended bool
+	err   error
+}
+
+func (s *fakeSpan) SetAttributes(attrs ...SpanAttr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *fakeSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+func (s *fakeSpan) TraceParent() string {
+	return "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+}
+
+func TestClientTracer(t *testing.T) {
+	traceParent := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"results":[{"type":"write","time":0.5,"rowsAffected":2},` +
+			`{"type":"write","time":0.5,"rowsAffected":3}]}`))
+	}))
+	defer server.Close()
+
+	tracer := &fakeTracer{}
+	client, err := NewClient(server.URL, WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	_, err = client.SendQueries(context.Background(), []Query{
+		{Query: "DELETE FROM a"},
+		{Query: "DELETE FROM b"},
+	})
+	if err != nil {
+		t.Fatalf("failed to send queries: %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "nsqlite.batch" || !span.ended || span.err != nil {
+		t.Errorf("unexpected span: %+v", span)
+	}
+	if traceParent != span.TraceParent() {
+		t.Errorf("expected traceparent %q, got %q", span.TraceParent(), traceParent)
+	}
+
+	wantAttrs := map[string]any{
+		SpanAttrDBSystem:       "nsqlite",
+		SpanAttrDBOperation:    "batch",
+		SpanAttrDBStatement:    "DELETE FROM a; DELETE FROM b",
+		SpanAttrBatchSize:      2,
+		SpanAttrStatementTypes: []string{"write", "write"},
+		SpanAttrRowsAffected:   int64(5),
+		SpanAttrServerTime:     1.0,
+	}
+	if !reflect.DeepEqual(span.attrs, wantAttrs) {
+		t.Errorf("expected attributes %v, got %v", wantAttrs, span.attrs)
+	}
+}